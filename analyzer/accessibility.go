@@ -0,0 +1,212 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Maximum length of the element reported along with an accessibility issue.
+const maxElementContextLength = 120
+
+// Link texts which do not describe the target of the link out of context.
+var genericLinkTexts = map[string]bool{
+	"click here": true, "click": true, "here": true, "this": true, "this link": true, "link": true,
+	"more": true, "read more": true, "learn more": true, "more info": true, "details": true, "continue": true,
+}
+
+// Landmark roles implied by elements. Other landmarks are given by the role attribute.
+var landmarkRoles = map[string]string{
+	"main":   "main",
+	"nav":    "navigation",
+	"aside":  "complementary",
+	"search": "search",
+}
+
+// Landmark roles implied by headers and footers, only when they are not inside sectioning elements.
+var topLevelLandmarkRoles = map[string]string{
+	"header": "banner",
+	"footer": "contentinfo",
+}
+
+// Elements which scope the headers and footers inside them to themselves.
+var sectioningElements = map[string]bool{
+	"article": true, "aside": true, "main": true, "nav": true, "section": true,
+}
+
+// Landmarks every page is expected to have, in the order they are reported when missing.
+var requiredLandmarks = []string{"main", "navigation", "banner", "contentinfo"}
+
+// collectAccessibility checks elements for common accessibility issues, such as images
+// without alt text and links or buttons without accessible names. Form fields are collected
+// to be checked for labels at the end, since labels may appear after the fields.
+func collectAccessibility(token *html.Token, status *parsingState) {
+	a := &status.a11y
+	if token.Type == html.EndTagToken {
+		if sectioningElements[token.Data] {
+			a.sectioningDepth = max(a.sectioningDepth-1, 0)
+		}
+		switch token.Data {
+		case "label":
+			a.labelDepth = max(a.labelDepth-1, 0)
+		case "a":
+			if link := a.currLink; link != nil {
+				a.checkLinkText(link)
+				a.currLink = nil
+			}
+		case "button":
+			if button := a.currButton; button != nil {
+				if !button.named && collapseSpaces(button.text.String()) == "" {
+					a.addIssue(MissingButtonName, button.context, "button has no text or aria-label")
+				}
+				a.currButton = nil
+			}
+		}
+		return
+	}
+
+	attrs := attrsOf(token)
+	if id := strings.TrimSpace(attrs["id"]); id != "" {
+		if a.ids[id] {
+			a.addIssue(DuplicateId, elementContext(token), fmt.Sprintf("id '%s' is used by more than one element", id))
+		}
+		a.ids[id] = true
+	}
+	if roles := strings.Fields(strings.ToLower(attrs["role"])); len(roles) > 0 {
+		a.landmarks[roles[0]] = true
+	}
+	if role, ok := landmarkRoles[token.Data]; ok {
+		a.landmarks[role] = true
+	} else if role, ok := topLevelLandmarkRoles[token.Data]; ok && a.sectioningDepth == 0 {
+		a.landmarks[role] = true
+	}
+	if sectioningElements[token.Data] && token.Type == html.StartTagToken {
+		a.sectioningDepth++
+	}
+
+	switch token.Data {
+	case "html":
+		if strings.TrimSpace(attrs["lang"]) != "" || strings.TrimSpace(attrs["xml:lang"]) != "" {
+			a.hasLang = true
+		}
+	case "img":
+		alt, hasAlt := attrs["alt"]
+		if !hasAlt && !hasAccessibleName(attrs) && attrs["aria-hidden"] != "true" {
+			a.addIssue(MissingAltText, elementContext(token), "image has no alt text")
+		}
+		// images in links and buttons are described by their alt text.
+		a.collectText(alt)
+	case "label":
+		if token.Type == html.StartTagToken {
+			a.labelDepth++
+		}
+		if target := strings.TrimSpace(attrs["for"]); target != "" {
+			a.labelFors[target] = true
+		}
+	case "input":
+		switch inputType := strings.ToLower(strings.TrimSpace(attrs["type"])); inputType {
+		case "hidden", "submit", "reset":
+			// submit and reset buttons have default names.
+		case "button", "image":
+			name := attrs["value"]
+			if inputType == "image" {
+				name = attrs["alt"]
+			}
+			if strings.TrimSpace(name) == "" && !hasAccessibleName(attrs) {
+				a.addIssue(MissingButtonName, elementContext(token), "button has no text or aria-label")
+			}
+		default:
+			a.fields = append(a.fields, a.newElement(token, attrs))
+		}
+	case "select", "textarea":
+		if token.Type == html.StartTagToken {
+			a.fields = append(a.fields, a.newElement(token, attrs))
+		}
+	case "a":
+		if _, ok := attrs["href"]; ok && token.Type == html.StartTagToken {
+			a.currLink = a.newElement(token, attrs)
+		}
+	case "button":
+		if token.Type == html.StartTagToken {
+			a.currButton = a.newElement(token, attrs)
+		}
+	}
+}
+
+func (a *accessibilityState) newElement(token *html.Token, attrs map[string]string) *accessibleElement {
+	return &accessibleElement{
+		context: elementContext(token),
+		id:      strings.TrimSpace(attrs["id"]),
+		named:   hasAccessibleName(attrs) || a.labelDepth > 0,
+	}
+}
+
+// collectText collects the given text into the link and button being parsed, if any.
+func (a *accessibilityState) collectText(content string) {
+	if a.currLink != nil {
+		a.currLink.text.WriteString(" " + content)
+	}
+	if a.currButton != nil {
+		a.currButton.text.WriteString(" " + content)
+	}
+}
+
+func (a *accessibilityState) checkLinkText(link *accessibleElement) {
+	if link.named {
+		return
+	}
+	text := collapseSpaces(link.text.String())
+	if text == "" {
+		a.addIssue(EmptyLinkText, link.context, "link has no text or aria-label")
+	} else if genericLinkTexts[strings.ToLower(strings.Trim(text, ".:!»›→… "))] {
+		a.addIssue(GenericLinkText, link.context, fmt.Sprintf("link text '%s' does not describe the target", text))
+	}
+}
+
+func (a *accessibilityState) addIssue(code, element, message string) {
+	a.issues = append(a.issues, AccessibilityIssue{Code: code, Element: element, Message: message})
+}
+
+// hasAccessibleName returns whether the element is named by aria attributes or a title.
+func hasAccessibleName(attrs map[string]string) bool {
+	return strings.TrimSpace(attrs["aria-label"]) != "" || strings.TrimSpace(attrs["aria-labelledby"]) != "" ||
+		strings.TrimSpace(attrs["title"]) != ""
+}
+
+// elementContext returns the start tag of the given element, shortened if too long.
+func elementContext(token *html.Token) string {
+	context := token.String()
+	if utf8.RuneCountInString(context) > maxElementContextLength {
+		context = string([]rune(context)[:maxElementContextLength-3]) + "..."
+	}
+	return context
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// deriveAccessibility reports the accessibility issues of the page. Issues of the whole page,
+// such as the missing lang and landmarks are reported first, followed by the issues of
+// elements, and then the form fields without labels.
+func deriveAccessibility(status *parsingState, info *AnalysisData) {
+	a := &status.a11y
+	var issues []AccessibilityIssue
+	if !a.hasLang {
+		issues = append(issues, AccessibilityIssue{Code: MissingLang, Message: "html element has no lang attribute"})
+	}
+	for _, landmark := range requiredLandmarks {
+		if !a.landmarks[landmark] {
+			issues = append(issues, AccessibilityIssue{Code: MissingLandmark, Message: fmt.Sprintf("page has no %s landmark", landmark)})
+		}
+	}
+	issues = append(issues, a.issues...)
+	for _, field := range a.fields {
+		if !field.named && (field.id == "" || !a.labelFors[field.id]) {
+			issues = append(issues, AccessibilityIssue{Code: MissingLabel, Element: field.context, Message: "form field has no label"})
+		}
+	}
+	info.AccessibilityIssues = issues
+}
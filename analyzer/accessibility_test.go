@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveAccessibility(t *testing.T) {
+	page := func(body string) string {
+		return `<!doctype html><html lang="en"><body><header></header><nav></nav><main>` + body + `</main><footer></footer></body></html>`
+	}
+	testcases := map[string]struct {
+		content string
+		issues  []AccessibilityIssue
+	}{
+		"Accessible Page": {
+			content: page(`<nav><a href="/docs">Documentation</a><a href="/"><img src="logo.png" alt="Home"></a></nav>
+				<img src="divider.png" alt="">
+				<label for="email">Email</label><input id="email" type="email">
+				<label>Name <input name="name"></label>
+				<input name="q" aria-label="Search"><select title="Country"></select>
+				<input type="hidden" name="token"><input type="submit"><input type="image" src="go.png" alt="Go">
+				<button><img src="close.png" alt="Close"></button><button aria-label="Menu"></button>
+				<a href="/more" aria-label="More about pricing">More</a>`),
+		},
+		"Page Issues": {
+			content: `<html><body><div role="navigation"><a href="/docs">Documentation</a></div></body></html>`,
+			issues: []AccessibilityIssue{
+				{Code: MissingLang, Message: "html element has no lang attribute"},
+				{Code: MissingLandmark, Message: "page has no main landmark"},
+				{Code: MissingLandmark, Message: "page has no banner landmark"},
+				{Code: MissingLandmark, Message: "page has no contentinfo landmark"},
+			},
+		},
+		"Landmarks By Role": {
+			content: `<html xml:lang="en"><div role="banner"></div><div role="navigation"></div><div role="main"></div><div role="contentinfo"></div></html>`,
+		},
+		"Missing Main Landmark": {
+			content: `<html lang="en"><body><header></header><nav></nav><div></div><footer></footer></body></html>`,
+			issues:  []AccessibilityIssue{{Code: MissingLandmark, Message: "page has no main landmark"}},
+		},
+		"Missing Navigation Landmark": {
+			content: `<html lang="en"><body><header></header><main></main><footer></footer></body></html>`,
+			issues:  []AccessibilityIssue{{Code: MissingLandmark, Message: "page has no navigation landmark"}},
+		},
+		"Missing Banner Landmark": {
+			content: `<html lang="en"><body><nav></nav><main></main><footer></footer></body></html>`,
+			issues:  []AccessibilityIssue{{Code: MissingLandmark, Message: "page has no banner landmark"}},
+		},
+		"Missing Contentinfo Landmark": {
+			content: `<html lang="en"><body><header></header><nav></nav><main></main></body></html>`,
+			issues:  []AccessibilityIssue{{Code: MissingLandmark, Message: "page has no contentinfo landmark"}},
+		},
+		"Headers And Footers In Sections": {
+			content: `<html lang="en"><body><nav></nav><main><header></header></main>
+				<article><header></header><section><footer></footer></section><footer></footer></article></body></html>`,
+			issues: []AccessibilityIssue{
+				{Code: MissingLandmark, Message: "page has no banner landmark"},
+				{Code: MissingLandmark, Message: "page has no contentinfo landmark"},
+			},
+		},
+		"Headers And Footers After Sections": {
+			content: `<html lang="en"><body><section><header></header></section><header></header>
+				<nav><footer></footer></nav><main></main><footer></footer></body></html>`,
+		},
+		"Images Without Alt": {
+			content: page(`<img src="chart.png"><img src="spacer.gif" aria-hidden="true"><img src="map.png" aria-label="Map">`),
+			issues:  []AccessibilityIssue{{Code: MissingAltText, Element: `<img src="chart.png">`, Message: "image has no alt text"}},
+		},
+		"Links Without Descriptive Text": {
+			content: page(`<a href="/a"></a><a href="/b"><img src="icon.png" alt=""></a><a href="/c">Click here</a>
+				<a href="/d">Read more…</a><a name="top"></a>`),
+			issues: []AccessibilityIssue{
+				{Code: EmptyLinkText, Element: `<a href="/a">`, Message: "link has no text or aria-label"},
+				{Code: EmptyLinkText, Element: `<a href="/b">`, Message: "link has no text or aria-label"},
+				{Code: GenericLinkText, Element: `<a href="/c">`, Message: "link text 'Click here' does not describe the target"},
+				{Code: GenericLinkText, Element: `<a href="/d">`, Message: "link text 'Read more…' does not describe the target"},
+			},
+		},
+		"Buttons Without Names": {
+			content: page(`<button class="close"><svg></svg></button><input type="button"><input type="image" src="go.png">`),
+			issues: []AccessibilityIssue{
+				{Code: MissingButtonName, Element: `<button class="close">`, Message: "button has no text or aria-label"},
+				{Code: MissingButtonName, Element: `<input type="button">`, Message: "button has no text or aria-label"},
+				{Code: MissingButtonName, Element: `<input type="image" src="go.png">`, Message: "button has no text or aria-label"},
+			},
+		},
+		"Fields Without Labels": {
+			content: page(`<input id="email" placeholder="Email"><label for="other">Other</label>
+				<textarea name="message"></textarea><select id="country"></select><label for="country">Country</label>`),
+			issues: []AccessibilityIssue{
+				{Code: MissingLabel, Element: `<input id="email" placeholder="Email">`, Message: "form field has no label"},
+				{Code: MissingLabel, Element: `<textarea name="message">`, Message: "form field has no label"},
+			},
+		},
+		"Duplicate Ids": {
+			content: page(`<div id="content"></div><p id="intro"></p><section id="content"></section>`),
+			issues: []AccessibilityIssue{
+				{Code: DuplicateId, Element: `<section id="content">`, Message: "id 'content' is used by more than one element"},
+			},
+		},
+		"Long Element": {
+			content: page(`<img src="/` + strings.Repeat("a", 200) + `.png">`),
+			issues: []AccessibilityIssue{
+				{Code: MissingAltText, Element: `<img src="/` + strings.Repeat("a", 106) + `...`, Message: "image has no alt text"},
+			},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			info := NewAnalysis("https://www.linklens.com/page")
+			status, err := parseHtmlContent(strings.NewReader(test.content), info, builtins()...)
+			assert.NoError(t, err)
+
+			// WHEN
+			deriveAccessibility(status, info)
+
+			// THEN
+			assert.Equal(t, test.issues, info.AccessibilityIssues)
+		})
+	}
+}
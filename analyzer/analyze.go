@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,9 +14,19 @@ import (
 	"golang.org/x/net/html"
 )
 
-var headingRegex = regexp.MustCompile(`(?i)h\d`)
+var headingRegex = regexp.MustCompile(`(?i)^h[1-6]$`)
 
-func AnalyzeUrl(getUrl string, crawler Crawler) (*AnalysisData, error) {
+var defaultAnalyzer = NewAnalyzer(Options{})
+
+// AnalyzeUrl analyzes the given url using an analyzer with default options.
+func AnalyzeUrl(ctx context.Context, getUrl string, crawler Crawler) (*AnalysisData, error) {
+	return defaultAnalyzer.AnalyzeUrl(ctx, getUrl, crawler)
+}
+
+// AnalyzeUrl fetches the given url and analyzes its content, and then crawls all
+// links found using the given crawler. If the context is cancelled during crawling,
+// then the partial analysis is returned and link statistics are marked as incomplete.
+func (a *Analyzer) AnalyzeUrl(ctx context.Context, getUrl string, crawler Crawler) (*AnalysisData, error) {
 	slog.Info("Starting the anlysis of ", "url", getUrl, "crawler", reflect.TypeOf(crawler).Elem())
 
 	parsedUrl, err := url.Parse(getUrl)
@@ -26,24 +37,61 @@ func AnalyzeUrl(getUrl string, crawler Crawler) (*AnalysisData, error) {
 		}
 	}
 
+	cacheKey := analysisCacheKey(getUrl, crawler, a.inspectors)
+	if info, ok := a.cachedAnalysis(cacheKey); ok {
+		slog.Info("Serving the analysis from cache", "url", getUrl, "storedAt", info.Cache.StoredAt)
+		return info, nil
+	}
+
 	info := NewAnalysis(getUrl)
-	status, errp := fetchUrlContent(parsedUrl, info)
+	names, inspectors := a.newInspectors()
+	status, errp := a.fetchUrlContent(ctx, parsedUrl, info, inspectors...)
 	if errp != nil {
 		return nil, errp
 	}
 
 	// crawl links
-	stats := crawler.Crawl(info.SourceUrl, status.allLinks)
+	baseUrl := status.documentBaseUrl(info.SourceUrl)
+	stats := crawler.Crawl(ctx, a, info.SourceUrl, baseUrl, status.allLinks)
 	info.LinkStats = *stats
+	info.ResourceStats = *crawlResources(ctx, a, crawler, info.SourceUrl, baseUrl, status.resources)
 
-	// guess page type...
-	derivePageType(status, info)
+	reportInspections(ctx, &Page{Analysis: info, status: status, analyzer: a}, names, inspectors)
 
+	// partial results are not cached, so that the next analysis completes them.
+	if !info.LinkStats.Incomplete && !info.ResourceStats.Incomplete {
+		a.cache(cacheKey, info)
+	}
 	return info, nil
 }
 
-func fetchUrlContent(url *url.URL, info *AnalysisData) (*parsingState, error) {
-	resp, err := http.Get(url.String())
+func (a *Analyzer) fetchUrlContent(ctx context.Context, url *url.URL, info *AnalysisData, inspectors ...Inspector) (*parsingState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		return nil, &AnalysisError{
+			ErrorCode: ErrorInvalidUrl,
+			Cause:     fmt.Errorf("given url is malformed"),
+		}
+	}
+
+	if !a.allowedByRobots(ctx, url.String()) {
+		return nil, &AnalysisError{
+			ErrorCode: BlockedByRobots,
+			Cause:     fmt.Errorf("robots.txt of the site disallows fetching the url"),
+		}
+	} else if err := a.waitForCrawlDelay(ctx, url.String()); err != nil {
+		return nil, &AnalysisError{
+			ErrorCode: RemoteFetchError,
+			Cause:     fmt.Errorf("cannot fetch the content from url"),
+		}
+	} else if err := a.rates.wait(ctx, url.String()); err != nil {
+		return nil, &AnalysisError{
+			ErrorCode: RemoteFetchError,
+			Cause:     fmt.Errorf("cannot fetch the content from url"),
+		}
+	}
+
+	resp, err := a.client.Do(req)
 	if err != nil {
 		return nil, &AnalysisError{
 			ErrorCode: RemoteFetchError,
@@ -69,79 +117,63 @@ func fetchUrlContent(url *url.URL, info *AnalysisData) (*parsingState, error) {
 	}
 
 	slog.Info("Recieved a valid html content from ", "url", url.String())
-	t := html.NewTokenizer(resp.Body)
-	status := &parsingState{inputTypeCounts: map[string]int{}, allLinks: map[string]bool{}}
+	return parseHtmlContent(resp.Body, info, inspectors...)
+}
 
-	for {
-		tokenType := t.Next()
+// parseHtmlContent tokenizes the given html content, and passes each token to the given
+// inspectors while collecting the base url of the document for resolving links.
+func parseHtmlContent(body io.Reader, info *AnalysisData, inspectors ...Inspector) (*parsingState, error) {
+	t := html.NewTokenizer(body)
+	status := &parsingState{
+		inputTypeCounts: map[string]int{},
+		allLinks:        map[string]string{},
+		resources:       map[string]string{},
+		metas:           map[string]string{},
+		a11y:            accessibilityState{ids: map[string]bool{}, labelFors: map[string]bool{}, landmarks: map[string]bool{}},
+	}
+	page := &Page{Analysis: info, status: status}
 
-		if tokenType == html.ErrorToken {
+	for {
+		switch t.Next() {
+		case html.ErrorToken:
 			err := t.Err()
 			if err == io.EOF {
 				err = nil
 			}
 			return status, err
+		case html.CommentToken:
+			continue
 		}
 
-		if tokenType == html.DoctypeToken {
-			html4Regex := regexp.MustCompile(`(?i)HTML\s+[4].*`)
-			doctag := string(t.Text())
-
-			result := html4Regex.FindStringSubmatch(doctag)
-			if result != nil {
-				info.HtmlVersion = "4"
-			}
-		}
-
-		if tokenType == html.TextToken {
-			processText(string(t.Text()), info, status)
-		}
-
-		if tokenType == html.StartTagToken || tokenType == html.EndTagToken || tokenType == html.SelfClosingTagToken {
-			node := t.Token()
-			processToken(&node, info, status)
+		token := t.Token()
+		collectBase(&token, status)
+		for _, inspector := range inspectors {
+			inspector.Inspect(&token, page)
 		}
 	}
 }
 
-func processToken(token *html.Token, info *AnalysisData, status *parsingState) {
-	if token.Type == html.StartTagToken || token.Type == html.SelfClosingTagToken {
-		if token.Data == "title" {
-			status.currTag = "title"
-		} else if headingRegex.MatchString(token.Data) {
-			info.HeadingsCount[strings.ToUpper(token.Data)]++
-		} else if token.Data == "a" {
-			for _, v := range token.Attr {
-				if v.Key == "href" {
-					status.allLinks[v.Val] = true
-					break
-				}
-			}
-		} else if token.Data == "input" {
-			for _, v := range token.Attr {
-				if v.Key == "type" {
-					status.inputTypeCounts[v.Val]++
-					break
-				}
-			}
-		}
-	} else if token.Type == html.EndTagToken {
-		if status.currTag != "" {
-			status.currTag = ""
-		}
+// collectBase collects the href of the first base element having a href, similar to browsers.
+func collectBase(token *html.Token, status *parsingState) {
+	if token.Data != "base" || status.hasBase || !isStartTag(token) {
+		return
 	}
-}
-
-func processText(content string, info *AnalysisData, status *parsingState) {
-	if status.currTag == "title" {
-		info.Title = content
+	for _, v := range token.Attr {
+		if v.Key == "href" {
+			status.baseHref = v.Val
+			status.hasBase = true
+			break
+		}
 	}
 }
 
-func derivePageType(status *parsingState, info *AnalysisData) {
-	if status.inputTypeCounts["password"] == 1 && status.inputTypeCounts["submit"] == 1 {
-		info.PageType = LoginForm
-	} else {
-		info.PageType = Unknown
+// documentBaseUrl returns the url which all relative links in the document resolves against.
+// That is the href of the base element if exists, or otherwise the source url of the document.
+func (status *parsingState) documentBaseUrl(sourceUrl string) string {
+	if status.hasBase {
+		if baseUrl, err := getFinalUrl(status.baseHref, sourceUrl); err == nil {
+			return baseUrl
+		}
 	}
+	return sourceUrl
 }
@@ -1,9 +1,14 @@
 package analyzer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
@@ -60,7 +65,7 @@ func TestAnalyzeUrl_Errors(t *testing.T) {
 			test.preRun()
 
 			// WHEN
-			_, err := AnalyzeUrl(test.url, &OneDepthCrawler{})
+			_, err := AnalyzeUrl(context.Background(), test.url, &OneDepthCrawler{})
 
 			// THEN
 			if err == nil {
@@ -92,6 +97,9 @@ func TestAnalyzeUrl_LinkTypesCrawl(t *testing.T) {
 		Reply(200).
 		AddHeader("content-type", "text/html").
 		BodyString(`<!doctype html><html>other-site</html>`)
+	gock.New("https://www.amazons3.com/s3/image.png").
+		Reply(200).
+		AddHeader("content-type", "image/png")
 
 	t.Run("Link Types Test", func(t *testing.T) {
 		// WHEN
@@ -101,15 +109,38 @@ func TestAnalyzeUrl_LinkTypesCrawl(t *testing.T) {
 		assert.Equal(t, &AnalysisData{
 			SourceUrl:     "https://www.linklens.com/a/b/c",
 			HtmlVersion:   "5",
+			Doctype:       html5Doctype,
 			Title:         "Test Title",
 			HeadingsCount: map[string]int{},
 			LinkStats: LinkStats{
 				InternalLinkCount: 3,
 				ExternalLinkCount: 1,
 				InvalidLinkCount:  0,
+				Links: []LinkStatus{
+					{Href: "#anchor", Url: "https://www.linklens.com/a/b/c#anchor", Kind: AnchorLink, Text: "anchor link"},
+					{Href: "/siterelative", Url: "https://www.linklens.com/siterelative", Kind: InternalLink, Text: "site rel link",
+						Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/siterelative"},
+					{Href: "https://www.othersite.com/test/x", Url: "https://www.othersite.com/test/x", Kind: ExternalLink, Text: "anchor link",
+						Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.othersite.com/test/x"},
+					{Href: "pathrelative/page1", Url: "https://www.linklens.com/a/b/pathrelative/page1", Kind: InternalLink, Text: "path rel link",
+						Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/a/b/pathrelative/page1"},
+				},
+			},
+			ResourceStats: ResourceStats{
+				ResourceCount: 1,
+				Types:         map[string]ResourceTypeStats{ImageResource: {Count: 1}},
+				Resources: []ResourceStatus{
+					{Type: ImageResource, LinkStatus: LinkStatus{Href: "https://www.amazons3.com/s3/image.png", Url: "https://www.amazons3.com/s3/image.png",
+						Kind: ExternalLink, Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.amazons3.com/s3/image.png"}},
+				},
 			},
 			PageType: Unknown,
-		}, info)
+			SeoIssues: []SeoIssue{
+				{Code: TitleTooShort, Message: "title is 10 characters long, shorter than 30"},
+				{Code: MissingDescription, Message: "page has no meta description"},
+			},
+			AccessibilityIssues: pageAccessibilityIssues,
+		}, withoutResponseTimes(info))
 	})
 }
 
@@ -151,6 +182,7 @@ func TestAnalyzeUrl_InAccessibleLinks(t *testing.T) {
 		assert.Equal(t, &AnalysisData{
 			SourceUrl:     "https://www.linklens.com/check/nx",
 			HtmlVersion:   "5",
+			Doctype:       html5Doctype,
 			Title:         "Test NX Links",
 			HeadingsCount: map[string]int{},
 			LinkStats: LinkStats{
@@ -163,9 +195,32 @@ func TestAnalyzeUrl_InAccessibleLinks(t *testing.T) {
 					"https://www.linklens.com/st-relative/nx",
 					"https://www.othersite.com/test/y/nx",
 				},
+				Links: []LinkStatus{
+					{Href: "#anchor", Url: "https://www.linklens.com/check/nx#anchor", Kind: AnchorLink, Text: "anchor link"},
+					{Href: "#anchor-nx", Url: "https://www.linklens.com/check/nx#anchor-nx", Kind: AnchorLink, Text: "anchor link"},
+					{Href: "/siterelative", Url: "https://www.linklens.com/siterelative", Kind: InternalLink, Text: "site rel link",
+						Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/siterelative"},
+					{Href: "/st-relative/nx", Url: "https://www.linklens.com/st-relative/nx", Kind: InternalLink, Text: "site rel link",
+						Verified: true, StatusCode: 999, ErrorCategory: ConnectionError},
+					{Href: "https://www.othersite.com/test/x", Url: "https://www.othersite.com/test/x", Kind: ExternalLink, Text: "anchor link",
+						Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.othersite.com/test/x"},
+					{Href: "https://www.othersite.com/test/y/nx", Url: "https://www.othersite.com/test/y/nx", Kind: ExternalLink, Text: "anchor link",
+						Verified: true, StatusCode: 999, ErrorCategory: ConnectionError},
+					{Href: "pathrelative/page1", Url: "https://www.linklens.com/check/pathrelative/page1", Kind: InternalLink, Text: "path rel link",
+						Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/check/pathrelative/page1"},
+					{Href: "pathrelative/pageerr", Url: "https://www.linklens.com/check/pathrelative/pageerr", Kind: InternalLink, Text: "path error link",
+						Verified: true, StatusCode: 999, ErrorCategory: ConnectionError},
+					{Href: "pathrelative/pagenx", Url: "https://www.linklens.com/check/pathrelative/pagenx", Kind: InternalLink, Text: "path rel link",
+						Verified: true, StatusCode: 404, ErrorCategory: HttpStatusError, FinalUrl: "https://www.linklens.com/check/pathrelative/pagenx"},
+				},
 			},
 			PageType: Unknown,
-		}, info)
+			SeoIssues: []SeoIssue{
+				{Code: TitleTooShort, Message: "title is 13 characters long, shorter than 30"},
+				{Code: MissingDescription, Message: "page has no meta description"},
+			},
+			AccessibilityIssues: pageAccessibilityIssues,
+		}, withoutResponseTimes(info))
 	})
 }
 
@@ -184,6 +239,7 @@ func TestAnalyzeUrl_HeadingCounts(t *testing.T) {
 			<h5 /><h5>  </h5>
 			<h6 /><H6></H6>
 			<hr/><hr></hr>
+			<h7>Not a heading</h7><h12></h12><th>Not a heading</th>
 		</body>
 		</html>`)
 
@@ -195,9 +251,32 @@ func TestAnalyzeUrl_HeadingCounts(t *testing.T) {
 		assert.Equal(t, &AnalysisData{
 			SourceUrl:     "https://www.linklens.com/test/headings",
 			HtmlVersion:   "5",
+			Doctype:       html5Doctype,
 			Title:         "Test Headings",
 			HeadingsCount: map[string]int{"H1": 2, "H2": 2, "H3": 2, "H4": 2, "H5": 2, "H6": 2},
-			PageType:      Unknown,
+			Outline: []Heading{
+				{Level: 1},
+				{Level: 1, Text: "Heading 1", Children: []Heading{
+					{Level: 2},
+					{Level: 2, Text: "Heading 2", Children: []Heading{
+						{Level: 3},
+						{Level: 3, Children: []Heading{
+							{Level: 4},
+							{Level: 4, Children: []Heading{
+								{Level: 5},
+								{Level: 5, Children: []Heading{{Level: 6}, {Level: 6}}},
+							}},
+						}},
+					}},
+				}},
+			},
+			PageType: Unknown,
+			SeoIssues: []SeoIssue{
+				{Code: TitleTooShort, Message: "title is 13 characters long, shorter than 30"},
+				{Code: MissingDescription, Message: "page has no meta description"},
+				{Code: MultipleH1, Message: "page has 2 h1 headings"},
+			},
+			AccessibilityIssues: pageAccessibilityIssues,
 		}, info)
 	})
 }
@@ -216,21 +295,21 @@ func TestAnalyzeUrl_HtmlVersion(t *testing.T) {
 				<html><title>Test HTML V4</title><body></body></html>`)
 			},
 			url:                 "https://www.linklens.com/test/htmlv4",
-			expectedHtmlVersion: "4",
+			expectedHtmlVersion: "4.0",
 		},
 		"HTML V5 Test": {
 			preRun: func() {
-				mockHtmlUrl("/test/htmlv5", `<!DOCTYPE HTML"><html><title>Test HTML V5</title><body></body></html>`)
+				mockHtmlUrl("/test/htmlv5", `<!DOCTYPE HTML><html><title>Test HTML V5</title><body></body></html>`)
 			},
 			url:                 "https://www.linklens.com/test/htmlv5",
 			expectedHtmlVersion: "5",
 		},
-		"Default Should Be V5": {
+		"Unknown Without Doctype": {
 			preRun: func() {
 				mockHtmlUrl("/test/htmlnx", `<html><title>Test HTML Default</title><body></body></html>`)
 			},
 			url:                 "https://www.linklens.com/test/htmlnx",
-			expectedHtmlVersion: "5",
+			expectedHtmlVersion: Unknown,
 		},
 	}
 
@@ -341,25 +420,67 @@ func TestAnalyzeUrl_IsLoginForm(t *testing.T) {
 		</body>
 		</html>`)
 
-	expected := func(url string, pageType string) *AnalysisData {
-		return &AnalysisData{
+	email := FormField{Element: "input", Type: "text", Name: "email"}
+	password := func(name string) FormField { return FormField{Element: "input", Type: "password", Name: name} }
+	submit := FormButton{Type: "submit"}
+
+	expected := func(url string, pageType string, fields []FormField, buttons []FormButton) *AnalysisData {
+		info := &AnalysisData{
 			SourceUrl:     url,
-			HtmlVersion:   "5",
+			HtmlVersion:   Unknown,
+			Doctype:       Doctype{Version: Unknown, Mode: QuirksMode},
 			Title:         "Test Login Form",
 			HeadingsCount: map[string]int{},
 			PageType:      pageType,
+			Forms:         []FormInfo{{Action: url, Method: "GET", Fields: fields, Buttons: buttons}},
+			SeoIssues: []SeoIssue{
+				{Code: TitleTooShort, Message: "title is 15 characters long, shorter than 30"},
+				{Code: MissingDescription, Message: "page has no meta description"},
+			},
+			AccessibilityIssues: append([]AccessibilityIssue{}, pageAccessibilityIssues...),
+		}
+		for _, field := range fields {
+			info.AccessibilityIssues = append(info.AccessibilityIssues, AccessibilityIssue{
+				Code: MissingLabel, Element: fmt.Sprintf(`<input type="%s" name="%s">`, field.Type, field.Name), Message: "form field has no label",
+			})
 		}
+		if pageType == LoginForm {
+			info.PageTypeConfidence = 1
+			info.PageTypeSignals = []string{
+				"single password input with a single submit input",
+				"form with a single password input",
+				"username or email input along with the password",
+			}
+		}
+		return info
 	}
 
 	testcases := map[string]struct {
 		url      string
 		pageType string
+		fields   []FormField
+		buttons  []FormButton
 	}{
-		"Should Be A Login Form":                  {pageType: LoginForm, url: "https://www.linklens.com/test/loginform"},
-		"No Login Form: Only Submit Button":       {pageType: Unknown, url: "https://www.linklens.com/test/loginsubmit"},
-		"No Login Form: Only Password Input":      {pageType: Unknown, url: "https://www.linklens.com/test/loginpw"},
-		"No Login Form: Multiple Password Inputs": {pageType: Unknown, url: "https://www.linklens.com/test/loginmultiplepw"},
-		"No Login Form: Multiple Submits":         {pageType: Unknown, url: "https://www.linklens.com/test/loginmultiplesubmit"},
+		"Should Be A Login Form": {
+			pageType: LoginForm, url: "https://www.linklens.com/test/loginform",
+			fields: []FormField{email, password("password")}, buttons: []FormButton{submit},
+		},
+		"No Login Form: Only Submit Button": {
+			pageType: Unknown, url: "https://www.linklens.com/test/loginsubmit",
+			fields: []FormField{email}, buttons: []FormButton{submit},
+		},
+		"No Login Form: Only Password Input": {
+			pageType: Unknown, url: "https://www.linklens.com/test/loginpw",
+			fields: []FormField{email, password("password")}, buttons: []FormButton{},
+		},
+		"No Login Form: Multiple Password Inputs": {
+			pageType: Unknown, url: "https://www.linklens.com/test/loginmultiplepw",
+			fields: []FormField{email, password("password1"), password("password2")}, buttons: []FormButton{submit},
+		},
+		"No Login Form: Multiple Submits": {
+			pageType: Unknown, url: "https://www.linklens.com/test/loginmultiplesubmit",
+			fields: []FormField{email, password("password")}, buttons: []FormButton{submit, submit},
+		},
 	}
 
 	for name, tcase := range testcases {
@@ -368,11 +489,203 @@ func TestAnalyzeUrl_IsLoginForm(t *testing.T) {
 			info := callAnalysisUrlSuccess(t, tcase.url)
 
 			// THEN
-			assert.Equal(t, expected(tcase.url, tcase.pageType), info)
+			assert.Equal(t, expected(tcase.url, tcase.pageType, tcase.fields, tcase.buttons), info)
 		})
 	}
 }
 
+func TestAnalyzeUrl_Cancellation(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		} else if r.URL.Path == "/cancel" {
+			w.Header().Add("content-type", "text/html")
+			fmt.Fprint(w, `<html><title>Slow Links</title><body>
+				<a href="/slow/1">slow link</a>
+				<a href="/slow/2">slow link</a>
+				<a href="/slow/3">slow link</a>
+			</body></html>`)
+			return
+		}
+		// slow links are never responded until the client cancels the request.
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// WHEN
+	info, err := AnalyzeUrl(ctx, server.URL+"/cancel", &OneDepthCrawler{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "Slow Links", info.Title)
+	assert.Equal(t, LinkStats{
+		InternalLinkCount: 3,
+		Links: []LinkStatus{
+			{Href: "/slow/1", Url: server.URL + "/slow/1", Kind: InternalLink, Text: "slow link"},
+			{Href: "/slow/2", Url: server.URL + "/slow/2", Kind: InternalLink, Text: "slow link"},
+			{Href: "/slow/3", Url: server.URL + "/slow/3", Kind: InternalLink, Text: "slow link"},
+		},
+		Incomplete: true,
+	}, info.LinkStats)
+}
+
+func TestAnalyzeUrl_LinkDetails(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockHtmlUrl("/test/linkdetails", `<!doctype html>
+		<html>
+		<body>
+			<a href="/docs">
+				Read   the
+				<b>docs</b>
+			</a>
+			<a href="/docs">Docs again</a>
+			<a href="/home"><img src="/logo.png" alt="Home"></a>
+			<a href="mailto:support@linklens.com">Contact us</a>
+			<a href="/empty"></a>
+		</body>
+		</html>`)
+	mockHtmlUrl("/docs", `<!doctype html><html></html>`)
+	mockHtmlUrl("/home", `<!doctype html><html></html>`)
+	mockHtmlUrlWithStatusCode("/empty", `<!doctype html><html></html>`, 500)
+
+	// WHEN
+	info := callAnalysisUrlSuccess(t, "https://www.linklens.com/test/linkdetails")
+
+	// THEN
+	assert.Equal(t, []LinkStatus{
+		{Href: "/docs", Url: "https://www.linklens.com/docs", Kind: InternalLink, Text: "Read the docs",
+			Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/docs"},
+		{Href: "/empty", Url: "https://www.linklens.com/empty", Kind: InternalLink,
+			Verified: true, StatusCode: 500, ErrorCategory: HttpStatusError, FinalUrl: "https://www.linklens.com/empty"},
+		{Href: "/home", Url: "https://www.linklens.com/home", Kind: InternalLink, Text: "Home",
+			Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/home"},
+		{Href: "mailto:support@linklens.com", Kind: MailtoLink, Text: "Contact us"},
+	}, withoutResponseTimes(info).LinkStats.Links)
+	assert.Equal(t, 3, info.LinkStats.InternalLinkCount)
+	assert.Equal(t, 1, info.LinkStats.MailtoLinkCount)
+}
+
+func TestAnalyzeUrl_BaseHref(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockHtmlUrl("/test/basehref", `<!doctype html>
+		<html>
+		<head>
+			<base href="/docs/v2/">
+			<base href="/ignored/">
+		</head>
+		<body>
+			<a href="intro">relative to base</a>
+			<a href="../v1/intro">parent of base</a>
+			<a href="/root">root relative</a>
+		</body>
+		</html>`)
+	mockHtmlUrl("/docs/v2/intro", `<!doctype html><html></html>`)
+	mockHtmlUrl("/docs/v1/intro", `<!doctype html><html></html>`)
+	mockHtmlUrl("/root", `<!doctype html><html></html>`)
+
+	// WHEN
+	info := callAnalysisUrlSuccess(t, "https://www.linklens.com/test/basehref")
+
+	// THEN
+	assert.Equal(t, 0, info.LinkStats.InvalidLinkCount)
+	resolved := []string{}
+	for _, link := range info.LinkStats.Links {
+		resolved = append(resolved, link.Url)
+	}
+	assert.Equal(t, []string{
+		"https://www.linklens.com/docs/v1/intro",
+		"https://www.linklens.com/root",
+		"https://www.linklens.com/docs/v2/intro",
+	}, resolved)
+}
+
+func TestAnalyzeUrl_CrossHostBaseHref(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockHtmlUrl("/test/cdnbase", `<!doctype html>
+		<html>
+		<head><base href="https://cdn.other.com/assets/"></head>
+		<body>
+			<a href="https://www.linklens.com/about">About</a>
+			<a href="guide.pdf">Guide</a>
+		</body>
+		</html>`)
+	mockHtmlUrl("/about", `<!doctype html><html></html>`)
+
+	// WHEN
+	info, err := AnalyzeUrl(context.Background(), "https://www.linklens.com/test/cdnbase", &OneDepthCrawler{CrawlConfig{SkipExternal: true}})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.LinkStats.InternalLinkCount)
+	assert.Equal(t, 1, info.LinkStats.ExternalLinkCount)
+	assert.Equal(t, []LinkStatus{
+		{Href: "guide.pdf", Url: "https://cdn.other.com/assets/guide.pdf", Kind: ExternalLink, Text: "Guide"},
+		{Href: "https://www.linklens.com/about", Url: "https://www.linklens.com/about", Kind: InternalLink, Text: "About", Verified: true, IsValid: true, StatusCode: 200, FinalUrl: "https://www.linklens.com/about"},
+	}, withoutResponseTimes(info).LinkStats.Links)
+}
+
+func TestAnalyzeUrl_Progress(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockHtmlUrl("/test/progress", `<!doctype html>
+		<html>
+		<body>
+			<a href="/docs">Docs</a>
+			<a href="/missing">Missing</a>
+			<a href="#top">Top</a>
+		</body>
+		</html>`)
+	mockHtmlUrl("/docs", `<!doctype html><html></html>`)
+	mockHtmlUrlWithStatusCode("/missing", `<!doctype html><html></html>`, 404)
+
+	var mu sync.Mutex
+	events := []ProgressEvent{}
+	a := defaultAnalyzer.WithListener(func(event ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	// WHEN
+	_, err := a.AnalyzeUrl(context.Background(), "https://www.linklens.com/test/progress", &OneDepthCrawler{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, ProgressEvent{Type: PageParsedEvent, PageUrl: "https://www.linklens.com/test/progress",
+		LinkCount: 3, PendingLinkCount: 2}, events[0])
+
+	checked := map[string]bool{}
+	for _, event := range events[1:] {
+		assert.Equal(t, LinkCheckedEvent, event.Type)
+		checked[event.Link.Href] = event.Link.IsValid
+	}
+	assert.Equal(t, map[string]bool{"/docs": true, "/missing": false}, checked)
+}
+
+var html5Doctype = Doctype{Present: true, Version: "5", Mode: StandardsMode}
+
+// Accessibility issues of test pages, which have neither a lang nor any landmarks.
+var pageAccessibilityIssues = []AccessibilityIssue{
+	{Code: MissingLang, Message: "html element has no lang attribute"},
+	{Code: MissingLandmark, Message: "page has no main landmark"},
+	{Code: MissingLandmark, Message: "page has no navigation landmark"},
+	{Code: MissingLandmark, Message: "page has no banner landmark"},
+	{Code: MissingLandmark, Message: "page has no contentinfo landmark"},
+}
+
 func mockHtmlUrl(path, response string) {
 	mockHtmlUrlWithStatusCode(path, response, 200)
 }
@@ -386,9 +699,23 @@ func mockHtmlUrlWithStatusCode(path, response string, statusCode int) {
 }
 
 func callAnalysisUrlSuccess(t *testing.T, url string) *AnalysisData {
-	info, err := AnalyzeUrl(url, &OneDepthCrawler{})
+	info, err := AnalyzeUrl(context.Background(), url, &OneDepthCrawler{})
 	if err != nil {
 		t.Fatalf("Not suppose to throw an error! %v", err)
 	}
 	return info
 }
+
+// withoutResponseTimes clears response times of all links, as they differ in each run.
+func withoutResponseTimes(info *AnalysisData) *AnalysisData {
+	for i := range info.LinkStats.Links {
+		info.LinkStats.Links[i].ResponseTimeMs = 0
+	}
+	for i := range info.LinkStats.RedirectedLinks {
+		info.LinkStats.RedirectedLinks[i].ResponseTimeMs = 0
+	}
+	for i := range info.ResourceStats.Resources {
+		info.ResourceStats.Resources[i].ResponseTimeMs = 0
+	}
+	return info
+}
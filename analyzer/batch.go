@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// AnalyzeUrls analyzes all given urls concurrently using the given crawler, and returns
+// the result of each url in the same order. All pages share a single budget of
+// simultaneous link checks, which is the concurrency configured for the crawler,
+// and a link found in many pages is verified only once.
+func (a *Analyzer) AnalyzeUrls(ctx context.Context, urls []string, crawler Crawler) []BatchResult {
+	batch := *a
+	batch.checks = newLinkChecks(batchConcurrency(crawler))
+
+	results := make([]BatchResult, len(urls))
+	jobs := make(chan int)
+	slog.Info("Starting the batch analysis", "urls#", len(urls))
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(DefaultBatchConcurrency, len(urls)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				data, err := batch.AnalyzeUrl(ctx, urls[idx], crawler)
+				results[idx] = BatchResult{Url: urls[idx], Data: data, Error: err}
+			}
+		}()
+	}
+
+	for idx := range urls {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	slog.Info("Finished the batch analysis", "urls#", len(urls), "verified#", batch.checks.count())
+	return results
+}
+
+// batchConcurrency returns the number of simultaneous link checks of the given crawler.
+// Custom crawlers without crawl configurations fallback to DefaultConcurrency.
+func batchConcurrency(crawler Crawler) int {
+	if configured, ok := crawler.(interface{ crawlConfig() *CrawlConfig }); ok {
+		return configured.crawlConfig().concurrency()
+	}
+	return DefaultConcurrency
+}
+
+// hostLimiter returns a new limiter of simultaneous requests per host, or the limiter
+// shared among all pages, when analyzing a batch.
+func (a *Analyzer) hostLimiter(limit int) *hostLimiter {
+	if a.checks != nil {
+		return a.checks.hostLimiter(limit)
+	}
+	return newHostLimiter(limit)
+}
+
+// linkChecks shares link verifications among all pages analyzed together.
+// Number of simultaneous checks is capped across all pages, and concurrent
+// checks of the same url wait for the first one instead of sending requests again.
+type linkChecks struct {
+	slots chan struct{}
+
+	mu      sync.Mutex
+	hosts   *hostLimiter
+	results map[string]*linkCheck
+}
+
+type linkCheck struct {
+	done   chan struct{}
+	status LinkStatus
+}
+
+func newLinkChecks(limit int) *linkChecks {
+	return &linkChecks{slots: make(chan struct{}, limit), results: map[string]*linkCheck{}}
+}
+
+// check returns the status of the given url, verifying it using the given function
+// only if the url has not been checked already. Checks aborted due to the context
+// cancellation are not shared, so that they can be retried.
+func (l *linkChecks) check(ctx context.Context, checkUrl string, verify func() LinkStatus) LinkStatus {
+	l.mu.Lock()
+	if existing, ok := l.results[checkUrl]; ok {
+		l.mu.Unlock()
+		select {
+		case <-existing.done:
+			return existing.status
+		case <-ctx.Done():
+			return cancelledStatus(ctx, checkUrl)
+		}
+	}
+	current := &linkCheck{done: make(chan struct{})}
+	l.results[checkUrl] = current
+	l.mu.Unlock()
+
+	defer close(current.done)
+	select {
+	case l.slots <- struct{}{}:
+		current.status = verify()
+		<-l.slots
+	case <-ctx.Done():
+		current.status = cancelledStatus(ctx, checkUrl)
+	}
+
+	if !current.status.IsValid && ctx.Err() != nil {
+		l.mu.Lock()
+		delete(l.results, checkUrl)
+		l.mu.Unlock()
+	}
+	return current.status
+}
+
+// hostLimiter returns the limiter shared among all pages, which is created on the first call.
+func (l *linkChecks) hostLimiter(limit int) *hostLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.hosts == nil {
+		l.hosts = newHostLimiter(limit)
+	}
+	return l.hosts
+}
+
+// count returns the number of distinct urls checked.
+func (l *linkChecks) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.results)
+}
+
+func cancelledStatus(ctx context.Context, checkUrl string) LinkStatus {
+	return LinkStatus{Url: checkUrl, StatusCode: 999, ErrorCategory: errorCategoryOf(ctx.Err())}
+}
@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeUrls(t *testing.T) {
+	// GIVEN
+	var footerChecks atomic.Int32
+	mux := http.NewServeMux()
+	for _, page := range []string{"/p1", "/p2", "/p3"} {
+		page := page
+		mux.HandleFunc(page, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprintf(w, `<html><head><title>%s</title></head><body>
+				<a href="%s">self</a>
+				<a href="/footer">footer</a>
+			</body></html>`, page, page)
+		})
+	}
+	mux.HandleFunc("/footer", func(w http.ResponseWriter, r *http.Request) {
+		footerChecks.Add(1)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	urls := []string{server.URL + "/p1", server.URL + "/missing", server.URL + "/p2", server.URL + "/p3"}
+
+	// WHEN
+	results := defaultAnalyzer.AnalyzeUrls(context.Background(), urls, &OneDepthCrawler{})
+
+	// THEN
+	assert.Len(t, results, 4)
+	for i, result := range results {
+		assert.Equal(t, urls[i], result.Url)
+	}
+
+	for _, idx := range []int{0, 2, 3} {
+		assert.NoError(t, results[idx].Error)
+		assert.Equal(t, urls[idx][len(server.URL):], results[idx].Data.Title)
+		assert.Equal(t, 0, results[idx].Data.LinkStats.InvalidLinkCount)
+		assert.True(t, results[idx].Data.LinkStats.Links[0].IsValid)
+		assert.Equal(t, server.URL+"/footer", results[idx].Data.LinkStats.Links[0].Url)
+	}
+
+	assert.Nil(t, results[1].Data)
+	assert.Equal(t, UnsuccessfulStatusCode, results[1].Error.(*AnalysisError).ErrorCode)
+
+	// shared link is verified only once across all pages.
+	assert.Equal(t, int32(1), footerChecks.Load())
+}
+
+func TestAnalyzeUrls_Concurrency(t *testing.T) {
+	// GIVEN
+	var inFlight, maxInFlight atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprintf(w, `<html><body><a href="/links%[1]s/1">1</a><a href="/links%[1]s/2">2</a><a href="/links%[1]s/3">3</a></body></html>`, r.URL.Path)
+	})
+	mux.HandleFunc("/links/", func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			if peak := maxInFlight.Load(); current <= peak || maxInFlight.CompareAndSwap(peak, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	urls := []string{server.URL + "/p1", server.URL + "/p2", server.URL + "/p3"}
+	crawler := &OneDepthCrawler{CrawlConfig{Concurrency: 2, PerHostConcurrency: 10}}
+
+	// WHEN
+	results := defaultAnalyzer.AnalyzeUrls(context.Background(), urls, crawler)
+
+	// THEN
+	for _, result := range results {
+		assert.NoError(t, result.Error)
+		assert.Equal(t, 0, result.Data.LinkStats.InvalidLinkCount)
+	}
+	// links of all pages share the configured concurrency.
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+}
+
+func TestAnalyzeUrls_Cancellation(t *testing.T) {
+	// GIVEN
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// WHEN
+	results := defaultAnalyzer.AnalyzeUrls(ctx, []string{"https://www.linklens.com/a", "https://www.linklens.com/b"}, &OneDepthCrawler{})
+
+	// THEN
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.Nil(t, result.Data)
+		assert.Equal(t, RemoteFetchError, result.Error.(*AnalysisError).ErrorCode)
+	}
+}
+
+func TestFetchSitemap(t *testing.T) {
+	// GIVEN
+	var otherFetches atomic.Int32
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+			<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<url><loc>%[1]s/a</loc><lastmod>2024-01-01</lastmod></url>
+				<url><loc> %[1]s/b </loc></url>
+				<url><loc>%[1]s/a</loc></url>
+			</urlset>`, server.URL)
+	})
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+			<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<sitemap><loc>%[1]s/sitemap.xml</loc></sitemap>
+				<sitemap><loc>%[1]s/other.xml</loc></sitemap>
+				<sitemap><loc>%[1]s/missing.xml</loc></sitemap>
+			</sitemapindex>`, server.URL)
+	})
+	mux.HandleFunc("/other.xml", func(w http.ResponseWriter, r *http.Request) {
+		otherFetches.Add(1)
+		fmt.Fprintf(w, `<urlset><url><loc>%[1]s/c</loc></url><url><loc>%[1]s/b</loc></url></urlset>`, server.URL)
+	})
+	mux.HandleFunc("/rss.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rss><channel></channel></rss>`)
+	})
+	mux.HandleFunc("/broken.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<urlset><url>`)
+	})
+
+	testcases := map[string]struct {
+		path         string
+		limit        int
+		urls         []string
+		truncated    bool
+		otherFetched bool
+		errorCode    string
+	}{
+		"Url Set":                    {path: "/sitemap.xml", urls: []string{server.URL + "/a", server.URL + "/b"}},
+		"Url Set Beyond Limit":       {path: "/sitemap.xml", limit: 1, urls: []string{server.URL + "/a"}, truncated: true},
+		"Sitemap Index":              {path: "/index.xml", urls: []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}, otherFetched: true},
+		"Sitemap Index Within Limit": {path: "/index.xml", limit: 4, urls: []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}, otherFetched: true},
+		"Sitemap Index Beyond Limit": {path: "/index.xml", limit: 2, urls: []string{server.URL + "/a", server.URL + "/b"}, truncated: true},
+		"Missing Sitemap":            {path: "/missing.xml", errorCode: UnsuccessfulStatusCode},
+		"Not A Sitemap":              {path: "/rss.xml", errorCode: InvalidSitemap},
+		"Malformed Sitemap Content":  {path: "/broken.xml", errorCode: InvalidSitemap},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			otherFetches.Store(0)
+
+			// WHEN
+			urls, truncated, err := defaultAnalyzer.FetchSitemap(context.Background(), server.URL+test.path, test.limit)
+
+			// THEN
+			if test.errorCode != "" {
+				assert.Equal(t, test.errorCode, err.(*AnalysisError).ErrorCode)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.urls, urls)
+				assert.Equal(t, test.truncated, truncated)
+				// nested sitemaps are not fetched once the limit is reached.
+				assert.Equal(t, test.otherFetched, otherFetches.Load() > 0)
+			}
+		})
+	}
+}
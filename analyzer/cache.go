@@ -0,0 +1,226 @@
+package analyzer
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores encoded analysis results and link statuses for a limited time.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value of the given key and the time it was stored,
+	// or false if the key does not exist or expired.
+	Get(key string) ([]byte, time.Time, bool)
+	// Set stores the value of the given key, replacing any existing value.
+	Set(key string, value []byte)
+}
+
+// MemoryCache keeps a limited number of entries in memory, and evicts the least
+// recently used entry when full. Entries expire after the ttl.
+type MemoryCache struct {
+	capacity int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key      string
+	value    []byte
+	storedAt time.Time
+}
+
+// NewMemoryCache creates a cache keeping maximum of the given number of entries.
+// Non-positive values fallback to the defaults.
+func NewMemoryCache(capacity int, ttl time.Duration) *MemoryCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheCapacity
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &MemoryCache{capacity: capacity, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if time.Since(entry.storedAt) > c.ttl {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, time.Time{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, entry.storedAt, true
+}
+
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+	}
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: value, storedAt: time.Now()})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// DiskCache keeps each entry in a separate file inside a directory, so that entries
+// survive restarts. Entries expire after the ttl based on the file modification time.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewDiskCache creates a cache storing entries in the given directory,
+// which is created if not exists.
+func NewDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, ttl: ttl}, nil
+}
+
+func (c *DiskCache) Get(key string) ([]byte, time.Time, bool) {
+	path := c.pathOf(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	} else if time.Since(info.ModTime()) > c.ttl {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("Unable to remove the expired cache entry", "path", path, "error", err)
+		}
+		return nil, time.Time{}, false
+	}
+
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	return value, info.ModTime(), true
+}
+
+func (c *DiskCache) Set(key string, value []byte) {
+	// written to a temporary file first, so that readers never see a partial entry.
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err == nil {
+		_, err = tmp.Write(value)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), c.pathOf(key))
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		slog.Warn("Unable to store the cache entry", "key", key, "error", err)
+	}
+}
+
+func (c *DiskCache) pathOf(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:]))
+}
+
+// WithCacheBypass returns a copy of the analyzer which does not use cached results,
+// but still caches the new results, so that they are used by later analyses.
+func (a *Analyzer) WithCacheBypass() *Analyzer {
+	copied := *a
+	copied.bypassCache = true
+	return &copied
+}
+
+// analysisCacheKey returns the cache key of the analysis of the given url,
+// which depends on the configuration of the crawler and the selected inspectors too.
+func analysisCacheKey(getUrl string, crawler Crawler, inspectors []string) string {
+	config, err := json.Marshal(crawler)
+	if err != nil {
+		config = []byte(fmt.Sprintf("%+v", crawler))
+	}
+	if len(inspectors) > 0 {
+		sorted := slices.Clone(inspectors)
+		slices.Sort(sorted)
+		return fmt.Sprintf("analysis|%T|%s|%s|%s", crawler, config, strings.Join(sorted, ","), getUrl)
+	}
+	return fmt.Sprintf("analysis|%T|%s|%s", crawler, config, getUrl)
+}
+
+func linkCacheKey(checkUrl string) string {
+	return "link|" + checkUrl
+}
+
+// cachedAnalysis returns the cached analysis of the given key along with its cache status.
+func (a *Analyzer) cachedAnalysis(key string) (*AnalysisData, bool) {
+	var info AnalysisData
+	storedAt, ok := a.cached(key, &info)
+	if !ok {
+		return nil, false
+	}
+	info.Cache = &CacheStatus{Hit: true, StoredAt: storedAt, AgeMs: time.Since(storedAt).Milliseconds()}
+	return &info, true
+}
+
+func (a *Analyzer) cachedLink(checkUrl string) (LinkStatus, bool) {
+	var status LinkStatus
+	_, ok := a.cached(linkCacheKey(checkUrl), &status)
+	return status, ok
+}
+
+func (a *Analyzer) cached(key string, target any) (time.Time, bool) {
+	if a.options.Cache == nil || a.bypassCache {
+		return time.Time{}, false
+	}
+
+	value, storedAt, ok := a.options.Cache.Get(key)
+	if !ok {
+		return time.Time{}, false
+	} else if err := json.Unmarshal(value, target); err != nil {
+		slog.Warn("Ignoring the malformed cache entry", "key", key, "error", err)
+		return time.Time{}, false
+	}
+	return storedAt, true
+}
+
+func (a *Analyzer) cache(key string, value any) {
+	if a.options.Cache == nil {
+		return
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		slog.Warn("Unable to encode the cache entry", "key", key, "error", err)
+		return
+	}
+	a.options.Cache.Set(key, content)
+}
@@ -0,0 +1,149 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Run("Evicts Least Recently Used", func(t *testing.T) {
+		// GIVEN
+		cache := NewMemoryCache(2, time.Minute)
+		cache.Set("a", []byte("1"))
+		cache.Set("b", []byte("2"))
+		cache.Get("a")
+
+		// WHEN
+		cache.Set("c", []byte("3"))
+
+		// THEN
+		_, _, ok := cache.Get("b")
+		assert.False(t, ok)
+		value, _, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+		value, _, ok = cache.Get("c")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("3"), value)
+	})
+
+	t.Run("Expires Entries", func(t *testing.T) {
+		// GIVEN
+		cache := NewMemoryCache(2, time.Millisecond)
+		cache.Set("a", []byte("1"))
+
+		// WHEN
+		time.Sleep(5 * time.Millisecond)
+
+		// THEN
+		_, _, ok := cache.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.order.Len())
+	})
+}
+
+func TestDiskCache(t *testing.T) {
+	// GIVEN
+	dir := filepath.Join(t.TempDir(), "cache")
+	cache, err := NewDiskCache(dir, time.Minute)
+	assert.NoError(t, err)
+
+	// WHEN
+	cache.Set("https://www.linklens.com/a", []byte("1"))
+	cache.Set("https://www.linklens.com/b", []byte("2"))
+	cache.Set("https://www.linklens.com/b", []byte("3"))
+
+	// THEN
+	value, storedAt, ok := cache.Get("https://www.linklens.com/a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.WithinDuration(t, time.Now(), storedAt, time.Minute)
+	value, _, ok = cache.Get("https://www.linklens.com/b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), value)
+	_, _, ok = cache.Get("https://www.linklens.com/c")
+	assert.False(t, ok)
+
+	// WHEN
+	expiredAt := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(cache.pathOf("https://www.linklens.com/a"), expiredAt, expiredAt))
+
+	// THEN
+	_, _, ok = cache.Get("https://www.linklens.com/a")
+	assert.False(t, ok)
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1)
+}
+
+func TestAnalyzeUrl_Cache(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	// mocks are not persisted, hence fetching the same url twice fails.
+	mockHtmlUrl("/test/cached", `<html><head><title>Cached</title></head><body><a href="/docs">docs</a></body></html>`)
+	mockHtmlUrl("/test/other", `<html><body><a href="/docs">docs</a></body></html>`)
+	mockHtmlUrl("/docs", `<html></html>`)
+	a := NewAnalyzer(Options{Cache: NewMemoryCache(10, time.Minute)})
+
+	// WHEN
+	first, err := a.AnalyzeUrl(context.Background(), "https://www.linklens.com/test/cached", &OneDepthCrawler{})
+	assert.NoError(t, err)
+	second, err := a.AnalyzeUrl(context.Background(), "https://www.linklens.com/test/cached", &OneDepthCrawler{})
+	assert.NoError(t, err)
+
+	// THEN
+	assert.Nil(t, first.Cache)
+	assert.True(t, second.Cache.Hit)
+	assert.WithinDuration(t, time.Now(), second.Cache.StoredAt, time.Minute)
+	second.Cache = nil
+	assert.Equal(t, first, second)
+
+	// WHEN
+	// same link found in another page is served from the cache.
+	other, err := a.AnalyzeUrl(context.Background(), "https://www.linklens.com/test/other", &OneDepthCrawler{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Nil(t, other.Cache)
+	assert.Equal(t, 0, other.LinkStats.InvalidLinkCount)
+
+	// WHEN
+	// other crawl configurations are analyzed separately.
+	_, err = a.AnalyzeUrl(context.Background(), "https://www.linklens.com/test/cached", &OneDepthCrawler{CrawlConfig{MaxLinks: 1}})
+
+	// THEN
+	assert.Error(t, err)
+
+	// WHEN
+	_, err = a.WithCacheBypass().AnalyzeUrl(context.Background(), "https://www.linklens.com/test/cached", &OneDepthCrawler{})
+
+	// THEN
+	assert.Error(t, err)
+}
+
+func TestAnalyzeUrl_CacheSkipsCancelledLinks(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockHtmlUrl("/test/incomplete", `<html><body><a href="/docs">docs</a></body></html>`)
+	mockHtmlUrl("/docs", `<html></html>`)
+	a := NewAnalyzer(Options{Cache: NewMemoryCache(10, time.Minute)})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// WHEN
+	stats := (&OneDepthCrawler{}).Crawl(ctx, a, "https://www.linklens.com/test/incomplete", "https://www.linklens.com/test/incomplete", map[string]string{"/docs": "docs"})
+	info, err := a.AnalyzeUrl(context.Background(), "https://www.linklens.com/test/incomplete", &OneDepthCrawler{})
+
+	// THEN
+	assert.True(t, stats.Incomplete)
+	assert.NoError(t, err)
+	assert.Nil(t, info.Cache)
+	assert.True(t, info.LinkStats.Links[0].IsValid)
+}
@@ -0,0 +1,294 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NewAnalyzer creates an analyzer which fetches pages and verifies links
+// using a http client configured by the given options.
+func NewAnalyzer(options Options) *Analyzer {
+	transport := newTransport(options)
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > options.maxRedirects() {
+				return fmt.Errorf("stopped after %d redirects", options.maxRedirects())
+			}
+			return nil
+		},
+	}
+	linkClient := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	a := &Analyzer{client: client, linkClient: linkClient, options: options, rates: newRateLimiter(options.HostRateLimit)}
+	if !options.IgnoreRobots {
+		a.robots = newRobotsCache()
+	}
+	return a
+}
+
+// WithListener returns a copy of the analyzer which notifies the given listener
+// about the progress of all analyses done using the copy.
+func (a *Analyzer) WithListener(listener Listener) *Analyzer {
+	copied := *a
+	copied.listener = listener
+	return &copied
+}
+
+func (a *Analyzer) notify(event ProgressEvent) {
+	if a.listener != nil {
+		a.listener(event)
+	}
+}
+
+func (o Options) maxRedirects() int {
+	if o.MaxRedirects == 0 {
+		return DefaultMaxRedirects
+	} else if o.MaxRedirects < 0 {
+		return 0
+	}
+	return o.MaxRedirects
+}
+
+func (o Options) userAgent() string {
+	if o.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return o.UserAgent
+}
+
+// newTransport creates the round tripper sending all requests of the analyzer.
+// Timeouts can only be applied on a *http.Transport, hence they are ignored
+// when a custom transport is given.
+func newTransport(options Options) http.RoundTripper {
+	base := options.Transport
+	if options.ConnectTimeout > 0 || options.ReadTimeout > 0 {
+		if base == nil {
+			base = http.DefaultTransport
+		}
+
+		if t, ok := base.(*http.Transport); ok {
+			t = t.Clone()
+			if options.ConnectTimeout > 0 {
+				t.DialContext = (&net.Dialer{Timeout: options.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+				t.TLSHandshakeTimeout = options.ConnectTimeout
+			}
+			if options.ReadTimeout > 0 {
+				t.ResponseHeaderTimeout = options.ReadTimeout
+			}
+			base = t
+		} else {
+			slog.Warn("Timeouts are not applied for custom transports!", "transport", fmt.Sprintf("%T", base))
+		}
+	}
+
+	return &headerTransport{base: base, userAgent: options.userAgent(), headers: options.Headers}
+}
+
+// headerTransport adds the user agent and extra headers to every outgoing request.
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+
+	// resolve the default transport lazily, so it can be replaced at any time. (e.g. in tests)
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// checkLink verifies whether the given link is a valid one or not
+// by checking whether it returns a 2xx response.
+// Redirects are followed one by one, so that the full redirect chain is recorded.
+// A link is invalid when redirects form a loop or exceed the maximum redirects.
+// Requests to each host are limited by the given host limiter, if any.
+// Note: This method does not strictly check the content-type.
+func (a *Analyzer) checkLink(ctx context.Context, checkUrl string, timeout time.Duration, hosts *hostLimiter) LinkStatus {
+	if status, ok := a.cachedLink(checkUrl); ok {
+		return status
+	}
+
+	var status LinkStatus
+	if a.checks != nil {
+		status = a.checks.check(ctx, checkUrl, func() LinkStatus {
+			return a.verifyLink(ctx, checkUrl, timeout, hosts)
+		})
+	} else {
+		status = a.verifyLink(ctx, checkUrl, timeout, hosts)
+	}
+
+	// statuses of cancelled checks are unknown, hence not cached.
+	if ctx.Err() == nil {
+		a.cache(linkCacheKey(checkUrl), status)
+	}
+	return status
+}
+
+// verifyLink verifies the given link by following its redirects one by one. Each redirected url
+// is checked against the robots.txt of its site, as the given link is checked by the callers,
+// and links redirecting to disallowed urls are marked as blocked without requesting them.
+func (a *Analyzer) verifyLink(ctx context.Context, checkUrl string, timeout time.Duration, hosts *hostLimiter) LinkStatus {
+	status := LinkStatus{Url: checkUrl, StatusCode: 999}
+	visited := map[string]bool{checkUrl: true}
+	currentUrl := checkUrl
+	for {
+		if currentUrl != checkUrl && !a.allowedByRobots(ctx, currentUrl) {
+			status.BlockedByRobots = true
+			return status
+		}
+
+		statusCode, header, elapsed, err := a.fetchHop(ctx, currentUrl, timeout, hosts)
+		status.ResponseTimeMs += elapsed.Milliseconds()
+		if err != nil {
+			// status code is unknown, hence the reason is reported only as the category
+			status.ErrorCategory = errorCategoryOf(err)
+			return status
+		}
+		status.StatusCode = statusCode
+
+		if !isRedirectStatus(statusCode) {
+			// we still dont care sites returning html content with with status code >=400
+			// e.g. Nginx 404/5xx
+			status.FinalUrl = currentUrl
+			status.IsValid = statusCode < 300
+			if !status.IsValid {
+				status.ErrorCategory = HttpStatusError
+			}
+			return status
+		}
+
+		status.Redirects = append(status.Redirects, RedirectHop{Url: currentUrl, StatusCode: statusCode})
+		nextUrl, err := resolveLocation(currentUrl, header.Get("Location"))
+		if err != nil {
+			status.ErrorCategory = UnresolvableUrl
+			return status
+		} else if len(status.Redirects) > a.options.maxRedirects() {
+			status.ErrorCategory = TooManyRedirectsError
+			return status
+		} else if visited[nextUrl] {
+			status.RedirectLoop = true
+			status.ErrorCategory = RedirectLoopError
+			return status
+		}
+
+		visited[nextUrl] = true
+		currentUrl = nextUrl
+	}
+}
+
+// fetchHop requests a single url of a redirect chain after waiting for a slot of its host and
+// the crawl delay of its site, and returns the time taken by the request along with its status.
+// The timeout applies to each request separately, so that links waiting for their turn in
+// a slow site do not time out.
+func (a *Analyzer) fetchHop(ctx context.Context, hopUrl string, timeout time.Duration, hosts *hostLimiter) (int, http.Header, time.Duration, error) {
+	if hosts != nil {
+		release, err := hosts.acquire(ctx, hopUrl)
+		if err != nil {
+			return 0, nil, 0, err
+		}
+		defer release()
+	}
+	if err := a.waitForCrawlDelay(ctx, hopUrl); err != nil {
+		return 0, nil, 0, err
+	}
+
+	startedAt := time.Now()
+	statusCode, header, err := a.findUrlStatusWithRetry(ctx, hopUrl, timeout)
+	return statusCode, header, time.Since(startedAt), err
+}
+
+// withTimeout returns a copy of the context which is cancelled after the given timeout.
+// Zero timeout means no timeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// findUrlStatus returns the status code and the response headers of the given url.
+// To avoid downloading the content, it first sends a HEAD request, and falls back
+// to a GET request asking only the first byte, if the server does not support HEAD.
+// Response bodies are never read.
+func (a *Analyzer) findUrlStatus(ctx context.Context, checkUrl string) (int, http.Header, error) {
+	resp, err := a.sendCheckRequest(ctx, http.MethodHead, checkUrl, false)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = a.sendCheckRequest(ctx, http.MethodGet, checkUrl, true)
+	}
+	if err == nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// e.g. empty content cannot satisfy any range
+		resp.Body.Close()
+		resp, err = a.sendCheckRequest(ctx, http.MethodGet, checkUrl, false)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// body is closed without reading, because only the status matters.
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header, nil
+}
+
+// sendCheckRequest sends a request to verify the given url using the given method.
+// When firstByteOnly is true, only the first byte of the content is requested.
+func (a *Analyzer) sendCheckRequest(ctx context.Context, method, checkUrl string, firstByteOnly bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, checkUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if firstByteOnly {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	return a.linkClient.Do(req)
+}
+
+// isRedirectStatus returns true if the status code asks to follow the location header.
+func isRedirectStatus(statusCode int) bool {
+	return isPermanentRedirect(statusCode) || isTemporaryRedirect(statusCode)
+}
+
+func isPermanentRedirect(statusCode int) bool {
+	return statusCode == http.StatusMovedPermanently || statusCode == http.StatusPermanentRedirect
+}
+
+func isTemporaryRedirect(statusCode int) bool {
+	return statusCode == http.StatusFound || statusCode == http.StatusSeeOther || statusCode == http.StatusTemporaryRedirect
+}
+
+// resolveLocation returns the absolute url of the location header relative to the url redirected from.
+func resolveLocation(fromUrl, location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("redirect location is empty")
+	}
+	base, err := url.Parse(fromUrl)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
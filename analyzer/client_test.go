@@ -0,0 +1,248 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAnalyzer_Headers(t *testing.T) {
+	// GIVEN
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	testcases := map[string]struct {
+		options   Options
+		userAgent string
+		headers   map[string]string
+	}{
+		"Default User Agent": {
+			options:   Options{},
+			userAgent: DefaultUserAgent,
+		},
+		"Custom User Agent And Headers": {
+			options:   Options{UserAgent: "TestAgent/2.0", Headers: map[string]string{"X-Token": "abc"}},
+			userAgent: "TestAgent/2.0",
+			headers:   map[string]string{"X-Token": "abc"},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			status := NewAnalyzer(test.options).checkLink(context.Background(), server.URL, 0, nil)
+
+			// THEN
+			assert.True(t, status.IsValid)
+			assert.Equal(t, test.userAgent, received.Get("User-Agent"))
+			for k, v := range test.headers {
+				assert.Equal(t, v, received.Get(k))
+			}
+		})
+	}
+}
+
+func TestNewAnalyzer_MaxRedirects(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/r1":
+			http.Redirect(w, r, "/r2", http.StatusFound)
+		case "/r2":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	testcases := map[string]struct {
+		maxRedirects int
+		valid        bool
+	}{
+		"Follows Within Limit": {maxRedirects: 2, valid: true},
+		"Stops Beyond Limit":   {maxRedirects: 1, valid: false},
+		"Redirects As Errors":  {maxRedirects: -1, valid: false},
+		"Default Limit":        {maxRedirects: 0, valid: true},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			status := NewAnalyzer(Options{MaxRedirects: test.maxRedirects}).checkLink(context.Background(), server.URL+"/r1", 0, nil)
+
+			// THEN
+			assert.Equal(t, test.valid, status.IsValid)
+			if !test.valid {
+				assert.Equal(t, TooManyRedirectsError, status.ErrorCategory)
+			}
+		})
+	}
+}
+
+func TestNewAnalyzer_ReadTimeout(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	// WHEN
+	start := time.Now()
+	status := NewAnalyzer(Options{ReadTimeout: 100 * time.Millisecond}).checkLink(context.Background(), server.URL, 0, nil)
+
+	// THEN
+	assert.False(t, status.IsValid)
+	assert.Equal(t, 999, status.StatusCode)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestCheckLink_HeadFirst(t *testing.T) {
+	testcases := map[string]struct {
+		headStatus int
+		getStatus  int
+		methods    []string
+		valid      bool
+		statusCode int
+	}{
+		"HEAD Supported": {
+			headStatus: http.StatusOK,
+			methods:    []string{"HEAD"},
+			valid:      true,
+			statusCode: http.StatusOK,
+		},
+		"HEAD Not Found": {
+			headStatus: http.StatusNotFound,
+			methods:    []string{"HEAD"},
+			valid:      false,
+			statusCode: http.StatusNotFound,
+		},
+		"HEAD Not Allowed": {
+			headStatus: http.StatusMethodNotAllowed,
+			getStatus:  http.StatusPartialContent,
+			methods:    []string{"HEAD", "GET bytes=0-0"},
+			valid:      true,
+			statusCode: http.StatusPartialContent,
+		},
+		"HEAD Not Implemented": {
+			headStatus: http.StatusNotImplemented,
+			getStatus:  http.StatusNotFound,
+			methods:    []string{"HEAD", "GET bytes=0-0"},
+			valid:      false,
+			statusCode: http.StatusNotFound,
+		},
+		"Range Not Satisfiable": {
+			headStatus: http.StatusMethodNotAllowed,
+			getStatus:  http.StatusRequestedRangeNotSatisfiable,
+			methods:    []string{"HEAD", "GET bytes=0-0", "GET"},
+			valid:      true,
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			methods := []string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/robots.txt" {
+					http.NotFound(w, r)
+					return
+				}
+				methods = append(methods, strings.TrimSpace(r.Method+" "+r.Header.Get("Range")))
+				if r.Method == http.MethodHead {
+					w.WriteHeader(test.headStatus)
+				} else if r.Header.Get("Range") != "" {
+					w.WriteHeader(test.getStatus)
+				} else {
+					w.WriteHeader(http.StatusOK)
+				}
+			}))
+			defer server.Close()
+
+			// WHEN
+			status := defaultAnalyzer.checkLink(context.Background(), server.URL+"/file.zip", 0, nil)
+
+			// THEN
+			assert.Equal(t, test.valid, status.IsValid)
+			assert.Equal(t, test.statusCode, status.StatusCode)
+			assert.Equal(t, test.methods, methods)
+		})
+	}
+}
+
+func TestCheckLink_Redirects(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/temp", http.StatusMovedPermanently)
+		case "/temp":
+			http.Redirect(w, r, "final", http.StatusTemporaryRedirect)
+		case "/loop1":
+			http.Redirect(w, r, "/loop2", http.StatusFound)
+		case "/loop2":
+			http.Redirect(w, r, "/loop1", http.StatusFound)
+		case "/broken":
+			http.Redirect(w, r, "/nx", http.StatusFound)
+		case "/nx":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testcases := map[string]struct {
+		path     string
+		expected LinkStatus
+	}{
+		"No Redirects": {
+			path: "/final",
+			expected: LinkStatus{Url: server.URL + "/final", IsValid: true, StatusCode: 200,
+				FinalUrl: server.URL + "/final"},
+		},
+		"Redirect Chain": {
+			path: "/moved",
+			expected: LinkStatus{Url: server.URL + "/moved", IsValid: true, StatusCode: 200,
+				Redirects: []RedirectHop{
+					{Url: server.URL + "/moved", StatusCode: 301},
+					{Url: server.URL + "/temp", StatusCode: 307},
+				},
+				FinalUrl: server.URL + "/final"},
+		},
+		"Redirect To Broken Link": {
+			path: "/broken",
+			expected: LinkStatus{Url: server.URL + "/broken", IsValid: false, StatusCode: 404, ErrorCategory: HttpStatusError,
+				Redirects: []RedirectHop{{Url: server.URL + "/broken", StatusCode: 302}},
+				FinalUrl:  server.URL + "/nx"},
+		},
+		"Redirect Loop": {
+			path: "/loop1",
+			expected: LinkStatus{Url: server.URL + "/loop1", IsValid: false, StatusCode: 302, ErrorCategory: RedirectLoopError,
+				Redirects: []RedirectHop{
+					{Url: server.URL + "/loop1", StatusCode: 302},
+					{Url: server.URL + "/loop2", StatusCode: 302},
+				},
+				RedirectLoop: true},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			status := defaultAnalyzer.checkLink(context.Background(), server.URL+test.path, 0, nil)
+
+			// THEN
+			status.ResponseTimeMs = 0
+			assert.Equal(t, test.expected, status)
+		})
+	}
+}
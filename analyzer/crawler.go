@@ -1,79 +1,370 @@
 package analyzer
 
 import (
+	"context"
 	"log/slog"
+	"net/url"
 	"slices"
+	"strings"
+	"sync"
 )
 
-// Crawl crawls all given links in the given base url and returns statistics about
+// Crawl crawls all given links in the given page and returns statistics about
 // the nature of links encountered. Such as, whether a link is internal, external or invalid.
 // Also, it reports all invalid links found separately.
-func (c *OneDepthCrawler) Crawl(baseUrl string, links map[string]bool) *LinkStats {
+func (c *OneDepthCrawler) Crawl(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, links map[string]string) *LinkStats {
 	linkStats := &LinkStats{}
-	if len(links) == 0 {
-		return linkStats
-	}
-
 	for link := range links {
-		if isAbsoluteUrl(link) {
-			linkStats.ExternalLinkCount++
-		} else {
+		switch c.linkKindOf(link, pageUrl, baseUrl) {
+		case InternalLink, AnchorLink:
 			linkStats.InternalLinkCount++
+		case ExternalLink:
+			linkStats.ExternalLinkCount++
+		case MailtoLink:
+			linkStats.MailtoLinkCount++
+		case TelLink:
+			linkStats.TelLinkCount++
+		case JavascriptLink:
+			linkStats.JavascriptLinkCount++
+		default:
+			linkStats.OtherSchemeLinkCount++
 		}
 	}
 
-	crawlForValidity(baseUrl, linkStats, links)
+	c.crawlForValidity(ctx, a, pageUrl, baseUrl, linkStats, links)
 	return linkStats
 }
 
-func crawlForValidity(baseUrl string, stats *LinkStats, links map[string]bool) {
-	invalidLinkChannel := make(chan LinkStatus)
-	count := 0
+// Crawl visits the source page and then follows its internal links breadth-first,
+// until either the maximum depth or the page budget is reached. Each visited page
+// is reported separately, while the returned statistics aggregate all pages.
+// When the context is cancelled, pages which are not visited yet will be skipped.
+func (c *DepthCrawler) Crawl(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, links map[string]string) *LinkStats {
+	linkStats := &LinkStats{}
+	visited := map[string]bool{stripFragment(pageUrl): true}
+	invalidLinks := map[string]bool{}
+	blockedLinks := map[string]bool{}
+	redirectedLinks := map[string]LinkStatus{}
+	allLinks := map[string]LinkStatus{}
+	pending := []pendingPage{{url: pageUrl, baseUrl: baseUrl, links: links}}
 
-	slog.Info("Starting crawling for links...", "site", baseUrl, "pending#", len(links))
-	for k := range links {
-		if !isAnchorLink(k) {
+	for len(pending) > 0 {
+		page := pending[0]
+		pending = pending[1:]
 
-			checkUrl := k
-			count++
+		if ctx.Err() != nil {
+			slog.Info("Crawling cancelled! Rest of pages will not be visited.", "site", pageUrl, "pending#", len(pending)+1)
+			linkStats.Incomplete = true
+			break
+		}
 
-			go func() {
-				crawlUrl(checkUrl, baseUrl, invalidLinkChannel)
-			}()
+		pageStats := (&OneDepthCrawler{CrawlConfig: c.CrawlConfig}).Crawl(ctx, a, page.url, page.baseUrl, page.links)
+		linkStats.Incomplete = linkStats.Incomplete || pageStats.Incomplete
+		linkStats.InternalLinkCount += pageStats.InternalLinkCount
+		linkStats.ExternalLinkCount += pageStats.ExternalLinkCount
+		linkStats.MailtoLinkCount += pageStats.MailtoLinkCount
+		linkStats.TelLinkCount += pageStats.TelLinkCount
+		linkStats.JavascriptLinkCount += pageStats.JavascriptLinkCount
+		linkStats.OtherSchemeLinkCount += pageStats.OtherSchemeLinkCount
+		for _, link := range pageStats.InvalidLinks {
+			invalidLinks[link] = true
+		}
+		for _, link := range pageStats.BlockedLinks {
+			blockedLinks[link] = true
+		}
+		for _, link := range pageStats.RedirectedLinks {
+			redirectedLinks[link.Url] = link
+		}
+		for _, link := range pageStats.Links {
+			// same link found in multiple pages is reported once, preferring a verified status.
+			if existing, ok := allLinks[link.displayUrl()]; !ok || (!existing.Verified && link.Verified) {
+				allLinks[link.displayUrl()] = link
+			}
+		}
+		linkStats.Pages = append(linkStats.Pages, PageReport{Url: page.url, Depth: page.depth, LinkStats: *pageStats})
+
+		if page.depth >= c.MaxDepth {
+			continue
+		}
+
+		for _, link := range sortedLinks(page.links) {
+			nextUrl, err := getFinalUrl(link, page.baseUrl)
+			if err != nil || c.linkKindOf(link, page.url, page.baseUrl) != InternalLink {
+				continue
+			}
+
+			nextUrl = stripFragment(nextUrl)
+			if visited[nextUrl] || invalidLinks[nextUrl] || len(visited) >= c.maxPages() {
+				continue
+			}
+			visited[nextUrl] = true
+
+			nextBaseUrl, nextLinks, err := a.fetchPageLinks(ctx, nextUrl)
+			if err != nil {
+				// non-html content or broken pages are already reported in the parent page.
+				slog.Info("Skipping page from crawling", "url", nextUrl, "reason", err)
+				continue
+			}
+			pending = append(pending, pendingPage{url: nextUrl, baseUrl: nextBaseUrl, depth: page.depth + 1, links: nextLinks})
 		}
 	}
 
+	for link := range invalidLinks {
+		linkStats.InvalidLinks = append(linkStats.InvalidLinks, link)
+	}
+	linkStats.InvalidLinkCount = len(linkStats.InvalidLinks)
+	slices.Sort(linkStats.InvalidLinks)
+
+	for link := range blockedLinks {
+		linkStats.BlockedLinks = append(linkStats.BlockedLinks, link)
+	}
+	linkStats.BlockedLinkCount = len(linkStats.BlockedLinks)
+	slices.Sort(linkStats.BlockedLinks)
+
+	for _, link := range redirectedLinks {
+		linkStats.addRedirectedLink(link)
+	}
+	sortRedirectedLinks(linkStats.RedirectedLinks)
+
+	for _, link := range allLinks {
+		linkStats.Links = append(linkStats.Links, link)
+	}
+	slices.SortFunc(linkStats.Links, func(a, b LinkStatus) int {
+		return strings.Compare(a.displayUrl(), b.displayUrl())
+	})
+	return linkStats
+}
+
+func (c *DepthCrawler) maxPages() int {
+	if c.MaxPages <= 0 {
+		return DefaultMaxPages
+	}
+	return c.MaxPages
+}
+
+// A page discovered during the crawl, which is yet to be checked.
+type pendingPage struct {
+	url string
+	// base url of the page, which all links are resolved against.
+	baseUrl string
+	depth   int
+	links   map[string]string
+}
+
+// sortedLinks returns the given links in sorted order, so the crawl order is predictable.
+func sortedLinks(links map[string]string) []string {
+	result := make([]string, 0, len(links))
+	for link := range links {
+		result = append(result, link)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// fetchPageLinks fetches the given page and returns all links found in it,
+// along with the base url which those links should be resolved against.
+func (a *Analyzer) fetchPageLinks(ctx context.Context, pageUrl string) (string, map[string]string, error) {
+	parsedUrl, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// only links are needed from the other pages, hence other inspectors are not run.
+	status, err := a.fetchUrlContent(ctx, parsedUrl, NewAnalysis(pageUrl), linksInspector{})
+	if err != nil {
+		return "", nil, err
+	}
+	return status.documentBaseUrl(pageUrl), status.allLinks, nil
+}
+
+// crawlForValidity verifies all given links of the page and records the status of each link in
+// the stats. When the context is cancelled, in-flight requests are aborted and the stats will be
+// marked as incomplete.
+func (c *CrawlConfig) crawlForValidity(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, stats *LinkStats, links map[string]string) {
+	pendingLinks := c.linksToVerify(pageUrl, baseUrl, links)
+
+	slog.Info("Starting crawling for links...", "site", pageUrl, "pending#", len(pendingLinks))
+	a.notify(ProgressEvent{Type: PageParsedEvent, PageUrl: pageUrl, LinkCount: len(links), PendingLinkCount: len(pendingLinks)})
+	invalidLinkChannel := c.verifyLinks(ctx, a, pageUrl, baseUrl, pendingLinks, links)
+
+	verifiedLinks := map[string]LinkStatus{}
 	for event := range invalidLinkChannel {
-		count--
+		verifiedLinks[event.Href] = event
+		link := event
+		a.notify(ProgressEvent{Type: LinkCheckedEvent, PageUrl: pageUrl, Link: &link})
 
-		if !event.IsValid {
-			slog.Info("Invalid link found!", "url", event.Url, "status", event.StatusCode)
+		if event.BlockedByRobots {
+			stats.BlockedLinkCount++
+			stats.BlockedLinks = append(stats.BlockedLinks, event.displayUrl())
+		} else if !event.IsValid {
+			slog.Info("Invalid link found!", "url", event.Url, "status", event.StatusCode, "reason", event.ErrorCategory)
 			stats.InvalidLinkCount++
-			stats.InvalidLinks = append(stats.InvalidLinks, event.Url)
+			stats.InvalidLinks = append(stats.InvalidLinks, event.displayUrl())
 		}
-
-		if count <= 0 {
-			close(invalidLinkChannel)
+		if len(event.Redirects) > 0 {
+			stats.addRedirectedLink(event)
 		}
 	}
 
 	// sort links so that similar links will be placed close together.
 	slices.Sort(stats.InvalidLinks)
+	slices.Sort(stats.BlockedLinks)
+	sortRedirectedLinks(stats.RedirectedLinks)
+
+	for _, href := range sortedLinks(links) {
+		if status, ok := verifiedLinks[href]; ok {
+			stats.Links = append(stats.Links, status)
+		} else {
+			stats.Links = append(stats.Links, c.newLinkStatus(href, links[href], pageUrl, baseUrl))
+		}
+	}
 
-	if stats.InvalidLinkCount == 0 {
+	if len(verifiedLinks) < len(pendingLinks) {
+		slog.Info("Crawling cancelled before verifying all links!", "site", pageUrl, "verified#", len(verifiedLinks))
+		stats.Incomplete = true
+	} else if stats.InvalidLinkCount == 0 {
 		slog.Info("No invalid links found!")
 	}
 
-	slog.Info("Finished crawling all links in the ", "site", baseUrl)
+	slog.Info("Finished crawling all links in the ", "site", pageUrl)
+}
+
+// verifyLinks verifies the given pending links using a bounded pool of workers, and sends
+// the status of each verified link to the returned channel, which is closed once all links
+// are verified or the context is cancelled. Number of simultaneous requests are capped
+// globally as well as per each host, so that a page with many links does not flood the
+// target hosts. Texts of the links are looked up from the given links, if any.
+func (c *CrawlConfig) verifyLinks(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, pendingLinks []string, links map[string]string) <-chan LinkStatus {
+	jobs := make(chan string)
+	results := make(chan LinkStatus)
+	hosts := a.hostLimiter(c.perHostConcurrency())
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.concurrency(), len(pendingLinks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for href := range jobs {
+				if status, ok := c.crawlUrl(ctx, a, href, links[href], pageUrl, baseUrl, hosts); ok {
+					results <- status
+				}
+			}
+		}()
+	}
+
+	go func() {
+	feed:
+		for _, link := range pendingLinks {
+			select {
+			case jobs <- link:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// crawlConfig returns the crawl configurations of the crawlers embedding them.
+func (c *CrawlConfig) crawlConfig() *CrawlConfig {
+	return c
+}
+
+func (c *CrawlConfig) concurrency() int {
+	if c.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return c.Concurrency
+}
+
+func (c *CrawlConfig) perHostConcurrency() int {
+	if c.PerHostConcurrency <= 0 {
+		return DefaultPerHostConcurrency
+	}
+	return c.PerHostConcurrency
+}
+
+// linksToVerify returns the links which need to be verified out of all given links,
+// after excluding anchor links, external links (if skipped) and links beyond the limit.
+func (c *CrawlConfig) linksToVerify(pageUrl, baseUrl string, links map[string]string) []string {
+	result := []string{}
+	for _, link := range sortedLinks(links) {
+		kind := c.linkKindOf(link, pageUrl, baseUrl)
+		if (kind != InternalLink && kind != ExternalLink) || (c.SkipExternal && kind == ExternalLink) {
+			continue
+		}
+		if c.MaxLinks > 0 && len(result) >= c.MaxLinks {
+			slog.Info("Maximum link limit reached! Rest of links will not be verified.", "site", pageUrl, "limit", c.MaxLinks)
+			break
+		}
+		result = append(result, link)
+	}
+	return result
+}
+
+// crawlUrl verifies the given href and returns its status. If the context is cancelled
+// before the verification completes, then it returns false as the result is unknown.
+func (c *CrawlConfig) crawlUrl(ctx context.Context, a *Analyzer, href, text, pageUrl, baseUrl string, hosts *hostLimiter) (LinkStatus, bool) {
+	status := c.newLinkStatus(href, text, pageUrl, baseUrl)
+	status.Verified = true
+	if status.Url == "" {
+		status.StatusCode = 999
+		status.ErrorCategory = UnresolvableUrl
+		return status, true
+	}
+
+	if !a.allowedByRobots(ctx, status.Url) {
+		status.Verified = false
+		status.BlockedByRobots = true
+		return status, ctx.Err() == nil
+	}
+
+	result := a.checkLink(ctx, status.Url, c.LinkTimeout, hosts)
+	if !result.IsValid && ctx.Err() != nil {
+		return LinkStatus{}, false
+	}
+	// links redirecting to urls disallowed by robots.txt are not verified.
+	result.Href, result.Kind, result.Text, result.Verified = status.Href, status.Kind, status.Text, !result.BlockedByRobots
+	return result, true
+}
+
+// newLinkStatus creates an unverified status of the given href found in the page having the base url.
+func (c *CrawlConfig) newLinkStatus(href, text, pageUrl, baseUrl string) LinkStatus {
+	status := LinkStatus{Href: href, Kind: c.linkKindOf(href, pageUrl, baseUrl), Text: text}
+	status.Url, _ = getFinalUrl(href, baseUrl)
+	return status
+}
+
+// linkKindOf returns the kind of the given href based on the configured internal domains.
+func (c *CrawlConfig) linkKindOf(href, pageUrl, baseUrl string) string {
+	return linkKindOf(href, pageUrl, baseUrl, c.InternalSubdomains, c.SiblingDomains)
 }
 
-func crawlUrl(url, baseUrl string, c chan LinkStatus) {
-	checkUrl, err := getFinalUrl(url, baseUrl)
-	isValid := false
-	statusCode := 999
-	if err == nil {
-		isValid, statusCode = findUrlValidity(checkUrl)
+// displayUrl returns the resolved url of the link, or the href if it cannot be resolved.
+func (s *LinkStatus) displayUrl() string {
+	if s.Url == "" {
+		return s.Href
 	}
+	return s.Url
+}
+
+// addRedirectedLink records the given redirected link, and counts it as a permanent
+// or temporary redirect based on the first redirect response.
+func (s *LinkStats) addRedirectedLink(link LinkStatus) {
+	s.RedirectedLinkCount++
+	if isPermanentRedirect(link.Redirects[0].StatusCode) {
+		s.PermanentRedirectCount++
+	} else {
+		s.TemporaryRedirectCount++
+	}
+	s.RedirectedLinks = append(s.RedirectedLinks, link)
+}
 
-	c <- LinkStatus{Url: checkUrl, IsValid: isValid, StatusCode: statusCode}
+func sortRedirectedLinks(links []LinkStatus) {
+	slices.SortFunc(links, func(a, b LinkStatus) int {
+		return strings.Compare(a.Url, b.Url)
+	})
 }
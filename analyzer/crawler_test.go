@@ -0,0 +1,346 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepthCrawler_Crawl(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockPersistedHtmlUrl("^/docs/$", `<html><body><a href="a">a</a></body></html>`, 200)
+	mockPersistedHtmlUrl("/docs/a", `<html><body>
+			<a href="/docs/c">c</a>
+			<a href="/docs/nx">broken</a>
+			<a href="/docs/">back to home</a>
+		</body></html>`, 200)
+	mockPersistedHtmlUrl("/docs/b", `<html><body>
+			<a href="a#section">a</a>
+		</body></html>`, 200)
+	mockPersistedHtmlUrl("/docs/c", `<html><body>
+			<a href="/docs/deep/nx">too deep</a>
+		</body></html>`, 200)
+	mockPersistedHtmlUrl("/docs/nx", `<html>not found</html>`, 404)
+	gock.New("https://www.othersite.com/test/x").
+		Persist().
+		Reply(200).
+		AddHeader("content-type", "text/html").
+		BodyString(`<!doctype html><html>other-site</html>`)
+
+	links := map[string]string{
+		"/docs/a":                          "",
+		"b":                                "",
+		"https://www.othersite.com/test/x": "",
+	}
+
+	testcases := map[string]struct {
+		crawler       *DepthCrawler
+		invalidLinks  []string
+		visitedPages  []string
+		internalCount int
+	}{
+		"Zero Depth Checks Only Source Page": {
+			crawler:       &DepthCrawler{MaxDepth: 0},
+			visitedPages:  []string{"https://www.linklens.com/docs/"},
+			internalCount: 2,
+		},
+		"One Depth Follows Internal Links": {
+			crawler:       &DepthCrawler{MaxDepth: 1},
+			invalidLinks:  []string{"https://www.linklens.com/docs/nx"},
+			visitedPages:  []string{"https://www.linklens.com/docs/", "https://www.linklens.com/docs/a", "https://www.linklens.com/docs/b"},
+			internalCount: 6,
+		},
+		"Two Depths Follows Nested Links": {
+			crawler:      &DepthCrawler{MaxDepth: 2},
+			invalidLinks: []string{"https://www.linklens.com/docs/deep/nx", "https://www.linklens.com/docs/nx"},
+			visitedPages: []string{"https://www.linklens.com/docs/", "https://www.linklens.com/docs/a",
+				"https://www.linklens.com/docs/b", "https://www.linklens.com/docs/c"},
+			internalCount: 7,
+		},
+		"Page Budget Limits Visited Pages": {
+			crawler:       &DepthCrawler{MaxDepth: 2, MaxPages: 2},
+			invalidLinks:  []string{"https://www.linklens.com/docs/nx"},
+			visitedPages:  []string{"https://www.linklens.com/docs/", "https://www.linklens.com/docs/a"},
+			internalCount: 5,
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			stats := test.crawler.Crawl(context.Background(), defaultAnalyzer, "https://www.linklens.com/docs/", "https://www.linklens.com/docs/", links)
+
+			// THEN
+			visited := []string{}
+			for _, page := range stats.Pages {
+				visited = append(visited, page.Url)
+			}
+			assert.Equal(t, test.visitedPages, visited)
+			assert.Equal(t, test.invalidLinks, stats.InvalidLinks)
+			assert.Equal(t, len(test.invalidLinks), stats.InvalidLinkCount)
+			assert.Equal(t, test.internalCount, stats.InternalLinkCount)
+			assert.Equal(t, 1, stats.ExternalLinkCount)
+		})
+	}
+}
+
+func TestOneDepthCrawler_CrawlConfig(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	mockPersistedHtmlUrl("/config/nx1", `<html>not found</html>`, 404)
+	mockPersistedHtmlUrl("/config/nx2", `<html>not found</html>`, 404)
+	gock.New("https://www.othersite.com/config/nx").
+		Persist().
+		Reply(404).
+		AddHeader("content-type", "text/html").
+		BodyString(`<!doctype html><html>not found</html>`)
+
+	links := map[string]string{
+		"#top":                                "",
+		"/config/nx1":                         "",
+		"/config/nx2":                         "",
+		"https://www.othersite.com/config/nx": "",
+	}
+
+	testcases := map[string]struct {
+		config       CrawlConfig
+		invalidLinks []string
+	}{
+		"Verifies All Links": {
+			config: CrawlConfig{},
+			invalidLinks: []string{"https://www.linklens.com/config/nx1", "https://www.linklens.com/config/nx2",
+				"https://www.othersite.com/config/nx"},
+		},
+		"Skips External Links": {
+			config:       CrawlConfig{SkipExternal: true},
+			invalidLinks: []string{"https://www.linklens.com/config/nx1", "https://www.linklens.com/config/nx2"},
+		},
+		"Limits Verified Links": {
+			config:       CrawlConfig{MaxLinks: 1},
+			invalidLinks: []string{"https://www.linklens.com/config/nx1"},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			stats := (&OneDepthCrawler{CrawlConfig: test.config}).Crawl(context.Background(), defaultAnalyzer, "https://www.linklens.com/config/", "https://www.linklens.com/config/", links)
+
+			// THEN
+			assert.Equal(t, test.invalidLinks, stats.InvalidLinks)
+			assert.Equal(t, 3, stats.InternalLinkCount)
+			assert.Equal(t, 1, stats.ExternalLinkCount)
+		})
+	}
+}
+
+func TestOneDepthCrawler_LinkClassification(t *testing.T) {
+	defer gock.Off()
+
+	// GIVEN
+	gock.New("https://www.linklens.com").Persist().Reply(200)
+	gock.New("https://docs.linklens.com").Persist().Reply(200)
+	gock.New("https://linklens.org").Persist().Reply(200)
+	gock.New("https://www.othersite.com").Persist().Reply(200)
+
+	links := map[string]string{
+		"#top":                              "",
+		"/about":                            "",
+		"https://www.linklens.com/contact":  "",
+		"https://docs.linklens.com/guide":   "",
+		"https://linklens.org/blog":         "",
+		"https://www.othersite.com/x":       "",
+		"mailto:info@linklens.com":          "",
+		"tel:+94112345678":                  "",
+		"javascript:void(0)":                "",
+		"ftp://files.linklens.com/file.zip": "",
+	}
+
+	testcases := map[string]struct {
+		config   CrawlConfig
+		internal int
+		external int
+	}{
+		"Same Host Only":              {config: CrawlConfig{}, internal: 3, external: 3},
+		"Subdomains As Internal":      {config: CrawlConfig{InternalSubdomains: true}, internal: 4, external: 2},
+		"Sibling Domains As Internal": {config: CrawlConfig{SiblingDomains: []string{"linklens.org"}}, internal: 4, external: 2},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			stats := (&OneDepthCrawler{CrawlConfig: test.config}).Crawl(context.Background(), defaultAnalyzer, "https://www.linklens.com/", "https://www.linklens.com/", links)
+
+			// THEN
+			assert.Equal(t, test.internal, stats.InternalLinkCount)
+			assert.Equal(t, test.external, stats.ExternalLinkCount)
+			assert.Equal(t, 1, stats.MailtoLinkCount)
+			assert.Equal(t, 1, stats.TelLinkCount)
+			assert.Equal(t, 1, stats.JavascriptLinkCount)
+			assert.Equal(t, 1, stats.OtherSchemeLinkCount)
+			assert.Equal(t, 0, stats.InvalidLinkCount)
+
+			verified := 0
+			for _, link := range stats.Links {
+				if link.Verified {
+					verified++
+				}
+			}
+			assert.Equal(t, 5, verified)
+		})
+	}
+}
+
+func TestOneDepthCrawler_OnlyAnchorLinks(t *testing.T) {
+	links := map[string]string{"#top": "Top", "#bottom": "Bottom"}
+
+	done := make(chan *LinkStats)
+	go func() {
+		done <- (&OneDepthCrawler{}).Crawl(context.Background(), defaultAnalyzer, "https://www.linklens.com/anchors", "https://www.linklens.com/anchors", links)
+	}()
+
+	select {
+	case stats := <-done:
+		assert.Equal(t, &LinkStats{
+			InternalLinkCount: 2,
+			Links: []LinkStatus{
+				{Href: "#bottom", Url: "https://www.linklens.com/anchors#bottom", Kind: AnchorLink, Text: "Bottom"},
+				{Href: "#top", Url: "https://www.linklens.com/anchors#top", Kind: AnchorLink, Text: "Top"},
+			},
+		}, stats)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Crawling only anchor links did not complete!")
+	}
+}
+
+func TestOneDepthCrawler_ConcurrencyLimits(t *testing.T) {
+	testcases := map[string]struct {
+		config   CrawlConfig
+		expected int
+	}{
+		"Global Limit":   {config: CrawlConfig{Concurrency: 3, PerHostConcurrency: 10}, expected: 3},
+		"Per Host Limit": {config: CrawlConfig{Concurrency: 10, PerHostConcurrency: 2}, expected: 2},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			var active, maxActive atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				current := active.Add(1)
+				defer active.Add(-1)
+				for {
+					prev := maxActive.Load()
+					if current <= prev || maxActive.CompareAndSwap(prev, current) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+			}))
+			defer server.Close()
+
+			links := map[string]string{}
+			for i := 0; i < 20; i++ {
+				links[fmt.Sprintf("/page/%d", i)] = ""
+			}
+
+			// WHEN
+			stats := (&OneDepthCrawler{CrawlConfig: test.config}).Crawl(context.Background(), defaultAnalyzer, server.URL, server.URL, links)
+
+			// THEN
+			assert.Equal(t, 0, stats.InvalidLinkCount)
+			assert.LessOrEqual(t, int(maxActive.Load()), test.expected)
+		})
+	}
+}
+
+func TestOneDepthCrawler_RedirectedHostLimit(t *testing.T) {
+	// GIVEN
+	var active, maxActive atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			return
+		}
+		current := active.Add(1)
+		defer active.Add(-1)
+		for {
+			prev := maxActive.Load()
+			if current <= prev || maxActive.CompareAndSwap(prev, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer target.Close()
+
+	links := map[string]string{}
+	for _, name := range []string{"a", "b"} {
+		source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+		}))
+		defer source.Close()
+		for i := 0; i < 10; i++ {
+			links[fmt.Sprintf("%s/%s/%d", source.URL, name, i)] = ""
+		}
+	}
+
+	// WHEN
+	stats := (&OneDepthCrawler{CrawlConfig: CrawlConfig{Concurrency: 10, PerHostConcurrency: 2}}).Crawl(context.Background(), NewAnalyzer(Options{}), target.URL, target.URL, links)
+
+	// THEN
+	assert.Equal(t, 0, stats.InvalidLinkCount)
+	assert.Equal(t, 20, stats.RedirectedLinkCount)
+	// links of different hosts redirecting to the same host share the limit of that host.
+	assert.LessOrEqual(t, int(maxActive.Load()), 2)
+}
+
+func TestOneDepthCrawler_RedirectStats(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/login":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	links := map[string]string{"/old": "Old", "/login": "Login", "/new": "New"}
+
+	// WHEN
+	stats := (&OneDepthCrawler{}).Crawl(context.Background(), defaultAnalyzer, server.URL, server.URL, links)
+
+	// THEN
+	assert.Equal(t, 0, stats.InvalidLinkCount)
+	assert.Equal(t, 2, stats.RedirectedLinkCount)
+	assert.Equal(t, 1, stats.PermanentRedirectCount)
+	assert.Equal(t, 1, stats.TemporaryRedirectCount)
+	assert.Equal(t, []LinkStatus{
+		{Href: "/login", Url: server.URL + "/login", Kind: InternalLink, Text: "Login", Verified: true,
+			IsValid: true, StatusCode: 200, FinalUrl: server.URL + "/new",
+			Redirects: []RedirectHop{{Url: server.URL + "/login", StatusCode: 302}}},
+		{Href: "/old", Url: server.URL + "/old", Kind: InternalLink, Text: "Old", Verified: true,
+			IsValid: true, StatusCode: 200, FinalUrl: server.URL + "/new",
+			Redirects: []RedirectHop{{Url: server.URL + "/old", StatusCode: 301}}},
+	}, withoutResponseTimes(&AnalysisData{LinkStats: *stats}).LinkStats.RedirectedLinks)
+}
+
+func mockPersistedHtmlUrl(path, response string, statusCode int) {
+	gock.New("https://www.linklens.com").
+		Path(path).
+		Persist().
+		Reply(statusCode).
+		AddHeader("content-type", "text/html").
+		BodyString(response)
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Versions and variants given by the public identifiers of legacy doctypes.
+// e.g. -//W3C//DTD HTML 4.01 Transitional//EN or -//W3C//DTD XHTML 1.0 Strict//EN
+var publicIdRegex = regexp.MustCompile(`(?i)//DTD (X?HTML)(?: Basic| Mobile)? (\d+(?:\.\d+)?)(?: (Strict|Transitional|Frameset))?`)
+
+// Public identifiers which trigger the quirks mode, when the public identifier starts with them.
+// See https://html.spec.whatwg.org/multipage/parsing.html#the-initial-insertion-mode
+var quirksPublicIdPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+// classifyDoctype finds the html version and variant declared by the given doctype, as given
+// by the tokenizer (i.e. without <!DOCTYPE and >), and the rendering mode it triggers in browsers.
+func classifyDoctype(text string) Doctype {
+	name, publicId, systemId, hasSystemId := parseDoctype(text)
+	doctype := Doctype{Present: true, Version: Unknown, Mode: renderingModeOf(name, publicId, systemId, hasSystemId)}
+
+	if match := publicIdRegex.FindStringSubmatch(publicId); match != nil {
+		doctype.Version = match[2]
+		if strings.EqualFold(match[1], "xhtml") {
+			doctype.Version = "XHTML " + match[2]
+		}
+		switch {
+		case match[3] != "":
+			doctype.Variant = strings.ToUpper(match[3][:1]) + strings.ToLower(match[3][1:])
+		case strings.HasPrefix(match[2], "4"):
+			// html 4 doctypes without a variant are strict. (e.g. -//W3C//DTD HTML 4.01//EN)
+			doctype.Variant = StrictVariant
+		}
+	} else if name == "html" && publicId == "" && (!hasSystemId || strings.EqualFold(systemId, "about:legacy-compat")) {
+		doctype.Version = "5"
+	}
+	return doctype
+}
+
+// parseDoctype parses the name, public identifier and system identifier of the given doctype.
+// e.g. html PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd"
+func parseDoctype(text string) (name, publicId, systemId string, hasSystemId bool) {
+	const whitespace = " \t\n\f\r"
+	text = strings.TrimLeft(text, whitespace)
+	end := strings.IndexAny(text, whitespace)
+	if end == -1 {
+		end = len(text)
+	}
+	name = strings.ToLower(text[:end])
+	text = strings.TrimLeft(text[end:], whitespace)
+	if len(text) < 6 {
+		return
+	}
+
+	keyword := strings.ToLower(text[:6])
+	text = text[6:]
+	for keyword == "public" || keyword == "system" {
+		text = strings.TrimLeft(text, whitespace)
+		if text == "" || (text[0] != '"' && text[0] != '\'') {
+			break
+		}
+		quote := text[0]
+		text = text[1:]
+		id := text
+		if end := strings.IndexByte(text, quote); end != -1 {
+			id, text = text[:end], text[end+1:]
+		} else {
+			text = ""
+		}
+
+		if keyword == "public" {
+			publicId = id
+			keyword = "system"
+		} else {
+			systemId, hasSystemId = id, true
+			keyword = ""
+		}
+	}
+	return
+}
+
+// renderingModeOf returns the mode which browsers render a page having the given doctype.
+func renderingModeOf(name, publicId, systemId string, hasSystemId bool) string {
+	publicId, systemId = strings.ToLower(publicId), strings.ToLower(systemId)
+	hasPrefix := func(prefixes ...string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(publicId, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	html4Prefixes := []string{"-//w3c//dtd html 4.01 frameset//", "-//w3c//dtd html 4.01 transitional//"}
+
+	switch {
+	case name != "html",
+		publicId == "-//w3o//dtd w3 html strict 3.0//en//", publicId == "-/w3c/dtd html 4.0 transitional/en", publicId == "html",
+		systemId == "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd",
+		hasPrefix(quirksPublicIdPrefixes...),
+		!hasSystemId && hasPrefix(html4Prefixes...):
+		return QuirksMode
+	case hasPrefix("-//w3c//dtd xhtml 1.0 frameset//", "-//w3c//dtd xhtml 1.0 transitional//"),
+		hasSystemId && hasPrefix(html4Prefixes...):
+		return LimitedQuirksMode
+	default:
+		return StandardsMode
+	}
+}
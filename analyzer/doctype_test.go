@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyDoctype(t *testing.T) {
+	testcases := map[string]struct {
+		doctype  string
+		expected Doctype
+	}{
+		"HTML 5": {
+			doctype:  `html`,
+			expected: Doctype{Version: "5", Mode: StandardsMode},
+		},
+		"HTML 5 Legacy Compat": {
+			doctype:  `HTML SYSTEM 'about:legacy-compat'`,
+			expected: Doctype{Version: "5", Mode: StandardsMode},
+		},
+		"HTML 4.01 Strict": {
+			doctype:  `HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd"`,
+			expected: Doctype{Version: "4.01", Variant: StrictVariant, Mode: StandardsMode},
+		},
+		"HTML 4.01 Transitional": {
+			doctype:  `html public "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd"`,
+			expected: Doctype{Version: "4.01", Variant: TransitionalVariant, Mode: LimitedQuirksMode},
+		},
+		"HTML 4.01 Transitional Without System Id": {
+			doctype:  `HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN"`,
+			expected: Doctype{Version: "4.01", Variant: TransitionalVariant, Mode: QuirksMode},
+		},
+		"HTML 4.01 Frameset": {
+			doctype:  `HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN" "http://www.w3.org/TR/html4/frameset.dtd"`,
+			expected: Doctype{Version: "4.01", Variant: FramesetVariant, Mode: LimitedQuirksMode},
+		},
+		"HTML 4.0 Transitional": {
+			doctype:  `HTML PUBLIC "-//W3C//DTD HTML 4.0 Transitional//EN"`,
+			expected: Doctype{Version: "4.0", Variant: TransitionalVariant, Mode: QuirksMode},
+		},
+		"XHTML 1.0 Strict": {
+			doctype:  `html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"`,
+			expected: Doctype{Version: "XHTML 1.0", Variant: StrictVariant, Mode: StandardsMode},
+		},
+		"XHTML 1.0 Transitional": {
+			doctype:  `html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd"`,
+			expected: Doctype{Version: "XHTML 1.0", Variant: TransitionalVariant, Mode: LimitedQuirksMode},
+		},
+		"XHTML 1.1": {
+			doctype:  `html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd"`,
+			expected: Doctype{Version: "XHTML 1.1", Mode: StandardsMode},
+		},
+		"HTML 3.2": {
+			doctype:  `HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN"`,
+			expected: Doctype{Version: "3.2", Mode: QuirksMode},
+		},
+		"HTML 2.0": {
+			doctype:  `HTML PUBLIC "-//IETF//DTD HTML 2.0//EN"`,
+			expected: Doctype{Version: "2.0", Mode: QuirksMode},
+		},
+		"Unknown Public Id": {
+			doctype:  `html PUBLIC "-//Example//DTD Custom//EN"`,
+			expected: Doctype{Version: Unknown, Mode: StandardsMode},
+		},
+		"Other System Id": {
+			doctype:  `html SYSTEM "http://www.example.com/custom.dtd"`,
+			expected: Doctype{Version: Unknown, Mode: StandardsMode},
+		},
+		"Missing Name": {
+			doctype:  ``,
+			expected: Doctype{Version: Unknown, Mode: QuirksMode},
+		},
+		"Malformed Name": {
+			doctype:  `HTML"`,
+			expected: Doctype{Version: Unknown, Mode: QuirksMode},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			doctype := classifyDoctype(test.doctype)
+
+			// THEN
+			test.expected.Present = true
+			assert.Equal(t, test.expected, doctype)
+		})
+	}
+}
+
+func TestParseHtmlContent_Doctype(t *testing.T) {
+	testcases := map[string]struct {
+		content     string
+		htmlVersion string
+		doctype     Doctype
+	}{
+		"No Doctype": {
+			content:     `<html><title>No Doctype</title></html>`,
+			htmlVersion: Unknown,
+			doctype:     Doctype{Version: Unknown, Mode: QuirksMode},
+		},
+		"First Doctype Only": {
+			content:     `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "x"><!doctype html><html></html>`,
+			htmlVersion: "XHTML 1.0",
+			doctype:     Doctype{Present: true, Version: "XHTML 1.0", Variant: StrictVariant, Mode: StandardsMode},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			info := NewAnalysis("https://www.linklens.com/page")
+
+			// WHEN
+			_, err := parseHtmlContent(strings.NewReader(test.content), info, builtins()...)
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, test.htmlVersion, info.HtmlVersion)
+			assert.Equal(t, test.doctype, info.Doctype)
+		})
+	}
+}
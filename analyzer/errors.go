@@ -1,12 +1,33 @@
 package analyzer
 
-import "fmt"
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+)
 
 const (
 	ErrorInvalidUrl        = "InvalidUrl"
 	RemoteFetchError       = "RemoteFetchError"
 	UnsuccessfulStatusCode = "UnsuccessfulStatusCode"
 	InvalidContentType     = "InvalidContentType"
+	InvalidSitemap         = "InvalidSitemap"
+	BlockedByRobots        = "BlockedByRobots"
+)
+
+// Categories of errors explaining why a link is invalid.
+const (
+	DnsError              = "DnsError"
+	TlsError              = "TlsError"
+	TimeoutError          = "TimeoutError"
+	ConnectionError       = "ConnectionError"
+	UnresolvableUrl       = "UnresolvableUrl"
+	HttpStatusError       = "HttpStatusError"
+	RedirectLoopError     = "RedirectLoopError"
+	TooManyRedirectsError = "TooManyRedirectsError"
 )
 
 type AnalysisError struct {
@@ -18,3 +39,24 @@ type AnalysisError struct {
 func (e *AnalysisError) Error() string {
 	return fmt.Sprintf("[%s] %s", e.ErrorCode, e.Cause.Error())
 }
+
+// errorCategoryOf returns the error category explaining the given request error.
+func errorCategoryOf(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	if errors.As(err, &dnsErr) {
+		return DnsError
+	} else if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return TlsError
+	} else if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return TimeoutError
+	}
+	return ConnectionError
+}
@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Names of hidden fields commonly used by frameworks to carry CSRF tokens.
+// (e.g. csrf_token, _csrf, authenticity_token, __RequestVerificationToken, _token)
+var csrfNameRegex = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity|verification|nonce|^_?token$)`)
+
+// collectFormToken collects forms along with their fields and buttons. Fields and buttons
+// found outside of forms are collected separately, as they still reveal the purpose of the page.
+func collectFormToken(token *html.Token, attrs map[string]string, status *parsingState) {
+	if token.Type == html.EndTagToken {
+		switch token.Data {
+		case "form":
+			status.currForm = nil
+		case "button":
+			if button := status.currButton; button != nil {
+				// buttons having only an icon are described by their aria label.
+				if text := strings.Join(strings.Fields(status.buttonText.String()), " "); text != "" {
+					button.Text = text
+				}
+				status.formOf().buttons = append(status.formOf().buttons, *button)
+				status.currButton = nil
+			}
+		}
+		return
+	}
+
+	switch token.Data {
+	case "form":
+		if token.Type == html.StartTagToken {
+			status.currForm = &formState{
+				action:      attrs["action"],
+				id:          attrs["id"],
+				name:        attrs["name"],
+				identifiers: strings.ToLower(strings.Join([]string{attrs["action"], attrs["id"], attrs["name"], attrs["class"]}, " ")),
+				method:      strings.ToLower(attrs["method"]),
+			}
+			status.forms = append(status.forms, status.currForm)
+		}
+	case "input":
+		inputType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+		if inputType == "" {
+			inputType = "text"
+		}
+		form := status.formOf()
+		switch inputType {
+		case "submit", "reset", "button", "image":
+			text := attrs["value"]
+			if inputType == "image" {
+				text = attrs["alt"]
+			}
+			form.buttons = append(form.buttons, FormButton{Type: inputType, Text: strings.Join(strings.Fields(text), " ")})
+		default:
+			form.inputs = append(form.inputs, newInputState("input", inputType, attrs))
+		}
+	case "select", "textarea":
+		if token.Type == html.StartTagToken {
+			status.formOf().inputs = append(status.formOf().inputs, newInputState(token.Data, "", attrs))
+		}
+	case "button":
+		if token.Type == html.StartTagToken {
+			buttonType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+			if buttonType != "reset" && buttonType != "button" {
+				buttonType = "submit"
+			}
+			status.currButton = &FormButton{Type: buttonType, Text: attrs["aria-label"]}
+			status.buttonText.Reset()
+		}
+	}
+}
+
+func newInputState(element, inputType string, attrs map[string]string) inputState {
+	_, required := attrs["required"]
+	return inputState{
+		element:      element,
+		inputType:    inputType,
+		name:         attrs["name"],
+		names:        strings.Join([]string{attrs["name"], attrs["id"], attrs["placeholder"]}, " "),
+		autocomplete: strings.ToLower(attrs["autocomplete"]),
+		required:     required,
+	}
+}
+
+// formOf returns the form being parsed, or the form collecting fields outside of forms.
+func (status *parsingState) formOf() *formState {
+	if status.currForm != nil {
+		return status.currForm
+	} else if status.looseInputs == nil {
+		status.looseInputs = &formState{}
+		status.forms = append(status.forms, status.looseInputs)
+	}
+	return status.looseInputs
+}
+
+// countInputs returns the number of fields matching the given condition.
+func (f *formState) countInputs(matches func(input inputState) bool) int {
+	count := 0
+	for _, input := range f.inputs {
+		if matches(input) {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *formState) hasInput(matches func(input inputState) bool) bool {
+	return f.countInputs(matches) > 0
+}
+
+// deriveForms builds the inventory of all forms in the page, resolving their actions
+// against the base url of the document. Fields outside of forms are not included.
+func deriveForms(status *parsingState, info *AnalysisData) {
+	baseUrl := status.documentBaseUrl(info.SourceUrl)
+	for _, form := range status.forms {
+		if form != status.looseInputs {
+			info.Forms = append(info.Forms, form.inventory(info.SourceUrl, baseUrl))
+		}
+	}
+}
+
+func (f *formState) inventory(pageUrl, baseUrl string) FormInfo {
+	// forms without an action are submitted to the page itself.
+	action := pageUrl
+	if strings.TrimSpace(f.action) != "" {
+		action = f.action
+		if resolved, err := getFinalUrl(f.action, baseUrl); err == nil {
+			action = resolved
+		}
+	}
+	method := strings.ToUpper(f.method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	form := FormInfo{Action: action, Method: method, Id: f.id, Name: f.name, Fields: []FormField{}, Buttons: f.buttons}
+	if form.Buttons == nil {
+		form.Buttons = []FormButton{}
+	}
+	for _, input := range f.inputs {
+		form.Fields = append(form.Fields, FormField{Element: input.element, Type: input.inputType, Name: input.name, Required: input.required})
+	}
+
+	actionUrl, actionErr := url.Parse(action)
+	page, pageErr := url.Parse(pageUrl)
+	if actionErr != nil || pageErr != nil || !isHttpUrl(actionUrl) {
+		return form
+	}
+	form.InsecurePassword = strings.EqualFold(actionUrl.Scheme, "http") &&
+		f.hasInput(func(input inputState) bool { return input.inputType == "password" })
+	form.MissingCsrfToken = method == http.MethodPost && !f.hasInput(func(input inputState) bool {
+		return input.inputType == "hidden" && csrfNameRegex.MatchString(input.name)
+	})
+	form.CrossOriginAction = !strings.EqualFold(actionUrl.Scheme, page.Scheme) || !strings.EqualFold(actionUrl.Host, page.Host)
+	return form
+}
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveForms(t *testing.T) {
+	// GIVEN
+	content := `<html><head><base href="https://www.linklens.com/app/"></head><body>
+		<form id="login" action="session" method="post">
+			<input type="hidden" name="authenticity_token" value="x">
+			<input name="user" required>
+			<input type="password" name="pwd" required="required">
+			<button><img src="icon.svg"> Sign in</button>
+			<button type="button" aria-label="Show password"><img src="eye.svg"></button>
+		</form>
+		<form name="signup" action="http://accounts.linklens.com/signup" method="POST">
+			<input type="email" name="email">
+			<input type="password" name="password">
+			<select name="country" required><option>LK</option></select>
+			<textarea name="bio"></textarea>
+			<input type="submit" value="Create account">
+			<input type="reset">
+		</form>
+		<form role="search"><input type="search" name="q"><input type="image" src="go.png" alt="Search"></form>
+		<input type="text" name="outside">
+	</body></html>`
+	info := NewAnalysis("https://www.linklens.com/page")
+	status, err := parseHtmlContent(strings.NewReader(content), info, builtins()...)
+	assert.NoError(t, err)
+
+	// WHEN
+	deriveForms(status, info)
+
+	// THEN
+	assert.Equal(t, []FormInfo{
+		{
+			Action: "https://www.linklens.com/app/session",
+			Method: "POST",
+			Id:     "login",
+			Fields: []FormField{
+				{Element: "input", Type: "hidden", Name: "authenticity_token"},
+				{Element: "input", Type: "text", Name: "user", Required: true},
+				{Element: "input", Type: "password", Name: "pwd", Required: true},
+			},
+			Buttons: []FormButton{{Type: "submit", Text: "Sign in"}, {Type: "button", Text: "Show password"}},
+		},
+		{
+			Action: "http://accounts.linklens.com/signup",
+			Method: "POST",
+			Name:   "signup",
+			Fields: []FormField{
+				{Element: "input", Type: "email", Name: "email"},
+				{Element: "input", Type: "password", Name: "password"},
+				{Element: "select", Name: "country", Required: true},
+				{Element: "textarea", Name: "bio"},
+			},
+			Buttons:           []FormButton{{Type: "submit", Text: "Create account"}, {Type: "reset"}},
+			InsecurePassword:  true,
+			MissingCsrfToken:  true,
+			CrossOriginAction: true,
+		},
+		{
+			Action:  "https://www.linklens.com/page",
+			Method:  "GET",
+			Fields:  []FormField{{Element: "input", Type: "search", Name: "q"}},
+			Buttons: []FormButton{{Type: "image", Text: "Search"}},
+		},
+	}, info.Forms)
+}
+
+func TestDeriveForms_NoForms(t *testing.T) {
+	// GIVEN
+	info := NewAnalysis("https://www.linklens.com/page")
+	status, err := parseHtmlContent(strings.NewReader(`<html><body><input name="q"><button>Go</button></body></html>`), info, builtins()...)
+	assert.NoError(t, err)
+
+	// WHEN
+	deriveForms(status, info)
+
+	// THEN
+	assert.Nil(t, info.Forms)
+}
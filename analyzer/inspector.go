@@ -0,0 +1,337 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A built-in inspector along with the inspectors it depends on.
+type builtinInspector struct {
+	name     string
+	factory  InspectorFactory
+	requires []string
+}
+
+// Built-in inspectors in the order they run. Inspectors are reported in the same order,
+// hence inspectors depending on the sections of others come later. Built-in inspectors
+// do not report sections, but fill the top-level fields of the analysis, and most of them
+// keep their state in the parsing state of the page, where each of them owns separate fields.
+var builtinInspectors = []builtinInspector{
+	{name: DoctypeInspector, factory: func() Inspector { return doctypeInspector{} }},
+	{name: TitleInspector, factory: func() Inspector { return &titleInspector{} }},
+	{name: HeadingsInspector, factory: func() Inspector { return headingsInspector{} }},
+	{name: LinksInspector, factory: func() Inspector { return linksInspector{} }},
+	{name: ResourcesInspector, factory: func() Inspector { return resourcesInspector{} }},
+	{name: FormsInspector, factory: func() Inspector { return formsInspector{} }},
+	{name: PageTypeInspector, factory: func() Inspector { return pageTypeInspector{} }, requires: []string{TitleInspector, FormsInspector}},
+	{name: SeoInspector, factory: func() Inspector { return seoInspector{} }, requires: []string{TitleInspector, HeadingsInspector}},
+	{name: AccessibilityInspector, factory: func() Inspector { return accessibilityInspector{} }},
+}
+
+// WithInspectors returns a copy of the analyzer which runs only the inspectors of the given
+// names, along with the inspectors they depend on. (e.g. seo depends on title and headings)
+// Sections of the other inspectors are left empty. Unknown names are ignored.
+func (a *Analyzer) WithInspectors(names ...string) *Analyzer {
+	copied := *a
+	copied.inspectors = names
+	return &copied
+}
+
+// InspectorNames returns the names of all inspectors of the analyzer, built-in ones first.
+func (a *Analyzer) InspectorNames() []string {
+	names := make([]string, 0, len(builtinInspectors)+len(a.options.Inspectors))
+	for _, inspector := range builtinInspectors {
+		names = append(names, inspector.name)
+	}
+	return append(names, a.customInspectorNames()...)
+}
+
+// customInspectorNames returns the names of the custom inspectors in sorted order,
+// skipping the ones clashing with built-in inspectors.
+func (a *Analyzer) customInspectorNames() []string {
+	var names []string
+	for name := range a.options.Inspectors {
+		if !isBuiltinInspector(name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func isBuiltinInspector(name string) bool {
+	return slices.ContainsFunc(builtinInspectors, func(inspector builtinInspector) bool {
+		return inspector.name == name
+	})
+}
+
+// newInspectors creates the selected inspectors for a single page in the order they run,
+// and returns them along with their names.
+func (a *Analyzer) newInspectors() ([]string, []Inspector) {
+	selected := map[string]bool{}
+	for _, name := range a.inspectors {
+		selected[name] = true
+	}
+	// dependencies come before the inspectors depending on them, hence the reverse order.
+	for i := len(builtinInspectors) - 1; i >= 0; i-- {
+		if selected[builtinInspectors[i].name] {
+			for _, name := range builtinInspectors[i].requires {
+				selected[name] = true
+			}
+		}
+	}
+
+	var names []string
+	var inspectors []Inspector
+	for _, builtin := range builtinInspectors {
+		if len(a.inspectors) == 0 || selected[builtin.name] {
+			names = append(names, builtin.name)
+			inspectors = append(inspectors, builtin.factory())
+		}
+	}
+	for _, name := range a.customInspectorNames() {
+		if len(a.inspectors) == 0 || selected[name] {
+			names = append(names, name)
+			inspectors = append(inspectors, a.options.Inspectors[name]())
+		}
+	}
+	return names, inspectors
+}
+
+// reportInspections reports the sections of all inspectors of the page in order. Built-in
+// inspectors populate the fields of the analysis, while the sections of custom inspectors
+// are added under their names.
+func reportInspections(ctx context.Context, page *Page, names []string, inspectors []Inspector) {
+	for i, inspector := range inspectors {
+		if section := inspector.Report(ctx, page); section != nil {
+			if page.Analysis.Sections == nil {
+				page.Analysis.Sections = map[string]any{}
+			}
+			page.Analysis.Sections[names[i]] = section
+		}
+	}
+}
+
+func isTag(token *html.Token) bool {
+	return token.Type == html.StartTagToken || token.Type == html.EndTagToken || token.Type == html.SelfClosingTagToken
+}
+
+func isStartTag(token *html.Token) bool {
+	return token.Type == html.StartTagToken || token.Type == html.SelfClosingTagToken
+}
+
+// doctypeInspector finds the html version and the rendering mode from the first doctype.
+type doctypeInspector struct{}
+
+func (doctypeInspector) Inspect(token *html.Token, page *Page) {
+	if token.Type == html.DoctypeToken && !page.status.hasDoctype {
+		page.Analysis.Doctype = classifyDoctype(token.Data)
+		page.Analysis.HtmlVersion = page.Analysis.Doctype.Version
+		page.status.hasDoctype = true
+	}
+}
+
+func (doctypeInspector) Report(ctx context.Context, page *Page) any {
+	return nil
+}
+
+// titleInspector finds the title of the page, which is the first title element outside of svg images.
+type titleInspector struct {
+	// depth of the svg element being parsed, as svg images have their own titles.
+	svgDepth int
+	inTitle  bool
+	found    bool
+}
+
+func (i *titleInspector) Inspect(token *html.Token, page *Page) {
+	switch {
+	case token.Type == html.TextToken && i.inTitle:
+		page.Analysis.Title = token.Data
+	case token.Type == html.StartTagToken && token.Data == "svg":
+		i.svgDepth++
+	case token.Type == html.EndTagToken && token.Data == "svg" && i.svgDepth > 0:
+		i.svgDepth--
+	case token.Type == html.StartTagToken && token.Data == "title":
+		i.inTitle = i.svgDepth == 0 && !i.found
+	case token.Type == html.EndTagToken && token.Data == "title" && i.inTitle:
+		i.inTitle, i.found = false, true
+	}
+}
+
+func (*titleInspector) Report(ctx context.Context, page *Page) any {
+	return nil
+}
+
+// headingsInspector counts the headings of each level, and builds the outline of the page.
+type headingsInspector struct{}
+
+func (headingsInspector) Inspect(token *html.Token, page *Page) {
+	status := page.status
+	if token.Type == html.TextToken {
+		if status.inHeading {
+			status.headingText.WriteString(" " + token.Data)
+		}
+		return
+	} else if !isTag(token) {
+		return
+	}
+
+	collectHeading(token, status)
+	if isStartTag(token) && headingRegex.MatchString(token.Data) {
+		page.Analysis.HeadingsCount[strings.ToUpper(token.Data)]++
+	}
+}
+
+func (headingsInspector) Report(ctx context.Context, page *Page) any {
+	deriveOutline(page.status, page.Analysis)
+	return nil
+}
+
+// linksInspector collects the links of the page along with their anchor text,
+// which are verified by the crawler afterwards.
+type linksInspector struct{}
+
+func (linksInspector) Inspect(token *html.Token, page *Page) {
+	status := page.status
+	if token.Type == html.TextToken {
+		if status.currLink != "" {
+			status.linkText.WriteString(" " + token.Data)
+		}
+		return
+	}
+
+	if isStartTag(token) {
+		if token.Data == "a" {
+			for _, v := range token.Attr {
+				if v.Key == "href" {
+					if _, exists := status.allLinks[v.Val]; !exists {
+						status.allLinks[v.Val] = ""
+					}
+					if token.Type == html.StartTagToken {
+						status.currLink = v.Val
+						status.linkText.Reset()
+					}
+					break
+				}
+			}
+		} else if token.Data == "img" && status.currLink != "" {
+			// image links are described by their alt text
+			for _, v := range token.Attr {
+				if v.Key == "alt" {
+					status.linkText.WriteString(" " + v.Val)
+					break
+				}
+			}
+		}
+	} else if token.Type == html.EndTagToken && token.Data == "a" && status.currLink != "" {
+		// same link may appear multiple times, and we keep the first non-empty text.
+		if text := strings.Join(strings.Fields(status.linkText.String()), " "); status.allLinks[status.currLink] == "" {
+			status.allLinks[status.currLink] = text
+		}
+		status.currLink = ""
+	}
+}
+
+func (linksInspector) Report(ctx context.Context, page *Page) any {
+	return nil
+}
+
+// resourcesInspector collects the resources loaded by the page, which are verified by the crawler afterwards.
+type resourcesInspector struct{}
+
+func (resourcesInspector) Inspect(token *html.Token, page *Page) {
+	if isTag(token) {
+		collectResources(token, page.status)
+	}
+}
+
+func (resourcesInspector) Report(ctx context.Context, page *Page) any {
+	return nil
+}
+
+// formsInspector builds the inventory of forms in the page.
+type formsInspector struct{}
+
+func (formsInspector) Inspect(token *html.Token, page *Page) {
+	status := page.status
+	if token.Type == html.TextToken {
+		if status.currButton != nil {
+			status.buttonText.WriteString(" " + token.Data)
+		}
+		return
+	} else if !isTag(token) {
+		return
+	}
+
+	collectFormToken(token, attrsOf(token), status)
+	if isStartTag(token) && token.Data == "input" {
+		for _, v := range token.Attr {
+			if v.Key == "type" {
+				status.inputTypeCounts[v.Val]++
+				break
+			}
+		}
+	}
+}
+
+func (formsInspector) Report(ctx context.Context, page *Page) any {
+	deriveForms(page.status, page.Analysis)
+	return nil
+}
+
+// pageTypeInspector identifies the type of the page using the forms, the title and other signals.
+type pageTypeInspector struct{}
+
+func (pageTypeInspector) Inspect(token *html.Token, page *Page) {
+	status := page.status
+	if token.Type == html.TextToken {
+		if status.inH1 {
+			status.h1Text.WriteString(" " + token.Data)
+		}
+		if status.paragraphDepth > 0 {
+			status.paragraphWords += len(strings.Fields(token.Data))
+		}
+		return
+	} else if isTag(token) {
+		collectPageSignals(token, status)
+	}
+}
+
+func (pageTypeInspector) Report(ctx context.Context, page *Page) any {
+	derivePageType(page.status, page.Analysis)
+	return nil
+}
+
+// seoInspector extracts the SEO metadata of the page, and audits the page for SEO issues.
+type seoInspector struct{}
+
+func (seoInspector) Inspect(token *html.Token, page *Page) {
+	if isTag(token) {
+		collectMetadata(token, page.status)
+	}
+}
+
+func (seoInspector) Report(ctx context.Context, page *Page) any {
+	deriveMetadata(page.status, page.Analysis)
+	page.analyzer.auditSeo(ctx, page.status, page.Analysis)
+	return nil
+}
+
+// accessibilityInspector audits the page for basic accessibility issues.
+type accessibilityInspector struct{}
+
+func (accessibilityInspector) Inspect(token *html.Token, page *Page) {
+	if token.Type == html.TextToken {
+		page.status.a11y.collectText(token.Data)
+	} else if isTag(token) {
+		collectAccessibility(token, page.status)
+	}
+}
+
+func (accessibilityInspector) Report(ctx context.Context, page *Page) any {
+	deriveAccessibility(page.status, page.Analysis)
+	return nil
+}
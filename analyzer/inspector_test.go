@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+// builtins creates all built-in inspectors for parsing a page.
+func builtins() []Inspector {
+	_, inspectors := defaultAnalyzer.newInspectors()
+	return inspectors
+}
+
+// wordCountInspector counts the words in paragraphs, and reports the count along with the page title.
+type wordCountInspector struct {
+	depth int
+	words int
+}
+
+func (i *wordCountInspector) Inspect(token *html.Token, page *Page) {
+	switch {
+	case token.Type == html.StartTagToken && token.Data == "p":
+		i.depth++
+	case token.Type == html.EndTagToken && token.Data == "p":
+		i.depth--
+	case token.Type == html.TextToken && i.depth > 0:
+		i.words += len(strings.Fields(token.Data))
+	}
+}
+
+func (i *wordCountInspector) Report(ctx context.Context, page *Page) any {
+	return fmt.Sprintf("%s: %d words", page.Analysis.Title, i.words)
+}
+
+// silentInspector reports no section.
+type silentInspector struct{}
+
+func (silentInspector) Inspect(token *html.Token, page *Page) {}
+
+func (silentInspector) Report(ctx context.Context, page *Page) any {
+	return nil
+}
+
+func TestAnalyzeUrl_Inspectors(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprint(w, `<!doctype html><html><head><title>Inspectors</title></head><body>
+				<h1>Plugins</h1><p>Inspectors are pluggable.</p><img src="/logo.png">
+				<form method="post"><input type="password" name="password"><input type="submit"></form>
+				<a href="/about">About</a></body></html>`)
+		case "/about", "/logo.png":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	a := NewAnalyzer(Options{Inspectors: map[string]InspectorFactory{
+		"wordCount": func() Inspector { return &wordCountInspector{} },
+		"silent":    func() Inspector { return silentInspector{} },
+		// built-in inspectors cannot be replaced.
+		TitleInspector: func() Inspector { return silentInspector{} },
+	}})
+
+	t.Run("All Inspectors", func(t *testing.T) {
+		// WHEN
+		info, err := a.AnalyzeUrl(context.Background(), server.URL+"/", &OneDepthCrawler{})
+
+		// THEN
+		assert.NoError(t, err)
+		assert.Equal(t, "5", info.HtmlVersion)
+		assert.Equal(t, "Inspectors", info.Title)
+		assert.Equal(t, map[string]int{"H1": 1}, info.HeadingsCount)
+		assert.Equal(t, 1, info.LinkStats.InternalLinkCount)
+		assert.Equal(t, 1, info.ResourceStats.ResourceCount)
+		assert.Len(t, info.Forms, 1)
+		assert.Equal(t, LoginForm, info.PageType)
+		assert.NotEmpty(t, info.SeoIssues)
+		assert.NotEmpty(t, info.AccessibilityIssues)
+		assert.Equal(t, map[string]any{"wordCount": "Inspectors: 3 words"}, info.Sections)
+	})
+
+	t.Run("Selected Inspectors", func(t *testing.T) {
+		// WHEN
+		info, err := a.WithInspectors(LinksInspector, "wordCount", "unknown").AnalyzeUrl(context.Background(), server.URL+"/", &OneDepthCrawler{})
+
+		// THEN
+		assert.NoError(t, err)
+		assert.Equal(t, Unknown, info.HtmlVersion)
+		assert.Empty(t, info.Title)
+		assert.Empty(t, info.HeadingsCount)
+		assert.Equal(t, 1, info.LinkStats.InternalLinkCount)
+		assert.Equal(t, 0, info.ResourceStats.ResourceCount)
+		assert.Empty(t, info.Forms)
+		assert.Empty(t, info.PageType)
+		assert.Empty(t, info.SeoIssues)
+		assert.Empty(t, info.AccessibilityIssues)
+		assert.Equal(t, map[string]any{"wordCount": ": 3 words"}, info.Sections)
+	})
+
+	t.Run("Required Inspectors", func(t *testing.T) {
+		// WHEN
+		info, err := a.WithInspectors(PageTypeInspector).AnalyzeUrl(context.Background(), server.URL+"/", &OneDepthCrawler{})
+
+		// THEN
+		assert.NoError(t, err)
+		assert.Equal(t, LoginForm, info.PageType)
+		assert.Equal(t, "Inspectors", info.Title)
+		assert.Len(t, info.Forms, 1)
+		assert.Empty(t, info.HeadingsCount)
+		assert.Equal(t, 0, info.LinkStats.InternalLinkCount)
+		assert.Nil(t, info.Sections)
+	})
+}
+
+func TestInspectorNames(t *testing.T) {
+	// GIVEN
+	a := NewAnalyzer(Options{Inspectors: map[string]InspectorFactory{
+		"wordCount":    func() Inspector { return &wordCountInspector{} },
+		"silent":       func() Inspector { return silentInspector{} },
+		LinksInspector: func() Inspector { return silentInspector{} },
+	}})
+
+	// WHEN
+	names := a.InspectorNames()
+
+	// THEN
+	assert.Equal(t, []string{
+		DoctypeInspector, TitleInspector, HeadingsInspector, LinksInspector, ResourcesInspector,
+		FormsInspector, PageTypeInspector, SeoInspector, AccessibilityInspector, "silent", "wordCount",
+	}, names)
+}
@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter limits the number of simultaneous requests sent to a single host.
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{limit: limit, slots: map[string]chan struct{}{}}
+}
+
+// acquire blocks until a slot is available for the host of the given url,
+// and returns a function to release the acquired slot. It returns an error
+// if the context is cancelled while waiting.
+func (l *hostLimiter) acquire(ctx context.Context, checkUrl string) (func(), error) {
+	slot := l.slotOf(hostOf(checkUrl))
+	select {
+	case slot <- struct{}{}:
+		return func() {
+			<-slot
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *hostLimiter) slotOf(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot, ok := l.slots[host]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[host] = slot
+	}
+	return slot
+}
+
+// rateLimiter spaces out requests sent to a single host, so that at most the given
+// number of requests are sent per second. Hosts asking to slow down (e.g. 429 responses
+// with a Retry-After header) are paused, regardless of the rate.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// newRateLimiter creates a limiter allowing the given number of requests per second
+// to a single host. Non-positive values do not limit the rate.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	var interval time.Duration
+	if perSecond > 0 {
+		interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return &rateLimiter{interval: interval, next: map[string]time.Time{}}
+}
+
+// wait blocks until a request can be sent to the host of the given url. It returns
+// an error if the context is cancelled while waiting.
+func (l *rateLimiter) wait(ctx context.Context, checkUrl string) error {
+	host := hostOf(checkUrl)
+	l.mu.Lock()
+	at := time.Now()
+	if next := l.next[host]; next.After(at) {
+		at = next
+	}
+	if l.interval > 0 {
+		// reserved in advance, so that concurrent requests are spaced out too.
+		l.next[host] = at.Add(l.interval)
+	}
+	l.mu.Unlock()
+
+	if !sleep(ctx, time.Until(at)) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// pause holds all requests to the host of the given url for the given duration.
+func (l *rateLimiter) pause(checkUrl string, duration time.Duration) {
+	host := hostOf(checkUrl)
+	until := time.Now().Add(duration)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.next[host]) {
+		l.next[host] = until
+	}
+}
+
+// hostOf returns the lower cased host of the given url, or empty if the url is malformed.
+func hostOf(checkUrl string) string {
+	u, err := url.Parse(checkUrl)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
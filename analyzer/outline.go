@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// collectHeading collects headings in the order of appearance along with their text.
+func collectHeading(token *html.Token, status *parsingState) {
+	if !headingRegex.MatchString(token.Data) {
+		return
+	}
+
+	status.finishHeading()
+	if token.Type == html.StartTagToken || token.Type == html.SelfClosingTagToken {
+		status.headings = append(status.headings, Heading{Level: int(token.Data[1] - '0')})
+		status.inHeading = token.Type == html.StartTagToken
+		status.headingText.Reset()
+	}
+}
+
+// finishHeading sets the text of the heading being parsed, if any.
+func (status *parsingState) finishHeading() {
+	if status.inHeading {
+		status.headings[len(status.headings)-1].Text = strings.Join(strings.Fields(status.headingText.String()), " ")
+		status.inHeading = false
+	}
+}
+
+// deriveOutline builds the outline of the document, where each heading is nested under
+// the closest previous heading of a higher level.
+func deriveOutline(status *parsingState, info *AnalysisData) {
+	status.finishHeading()
+
+	root := &Heading{}
+	parents := []*Heading{root}
+	for _, heading := range status.headings {
+		for parents[len(parents)-1].Level >= heading.Level {
+			parents = parents[:len(parents)-1]
+		}
+		parent := parents[len(parents)-1]
+		heading.SkippedLevels = heading.Level - parent.Level - 1
+		parent.Children = append(parent.Children, heading)
+		// the parent gets no more children until this heading is popped, hence the pointer stays valid.
+		parents = append(parents, &parent.Children[len(parent.Children)-1])
+	}
+	info.Outline = root.Children
+}
+
+// walkOutline calls the given function for each heading of the outline in the order of
+// appearance, along with the level of its parent heading. (zero for top level headings)
+func walkOutline(headings []Heading, parentLevel int, visit func(heading *Heading, parentLevel int)) {
+	for i := range headings {
+		visit(&headings[i], parentLevel)
+		walkOutline(headings[i].Children, headings[i].Level, visit)
+	}
+}
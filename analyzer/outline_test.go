@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveOutline(t *testing.T) {
+	testcases := map[string]struct {
+		content string
+		outline []Heading
+	}{
+		"Nested Headings": {
+			content: `<h1>Guide</h1><h2>Install</h2><h3>Linux</h3><h3>Mac</h3><h2>Usage</h2><h1>FAQ</h1>`,
+			outline: []Heading{
+				{Level: 1, Text: "Guide", Children: []Heading{
+					{Level: 2, Text: "Install", Children: []Heading{{Level: 3, Text: "Linux"}, {Level: 3, Text: "Mac"}}},
+					{Level: 2, Text: "Usage"},
+				}},
+				{Level: 1, Text: "FAQ"},
+			},
+		},
+		"Skipped Levels": {
+			content: `<h2>Intro</h2><h1>Guide</h1><h4>Details</h4><h2>Usage</h2><h6>Note</h6>`,
+			outline: []Heading{
+				{Level: 2, Text: "Intro", SkippedLevels: 1},
+				{Level: 1, Text: "Guide", Children: []Heading{
+					{Level: 4, Text: "Details", SkippedLevels: 2},
+					{Level: 2, Text: "Usage", Children: []Heading{{Level: 6, Text: "Note", SkippedLevels: 3}}},
+				}},
+			},
+		},
+		"Text Of Nested Elements": {
+			content: `<h1>
+				<a href="/">Link <b>Lens</b></a>
+				<small>docs</small>
+			</h1><H2>Upper Case</H2>`,
+			outline: []Heading{{Level: 1, Text: "Link Lens docs", Children: []Heading{{Level: 2, Text: "Upper Case"}}}},
+		},
+		"Unclosed Heading": {
+			content: `<h1>Guide<h2>Install`,
+			outline: []Heading{{Level: 1, Text: "Guide", Children: []Heading{{Level: 2, Text: "Install"}}}},
+		},
+		"Non Heading Tags": {
+			content: `<h0>Zero</h0><h7>Seven</h7><hr><th>Header</th><h10>Ten</h10>`,
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			info := NewAnalysis("https://www.linklens.com/page")
+			status, err := parseHtmlContent(strings.NewReader(test.content), info, builtins()...)
+			assert.NoError(t, err)
+
+			// WHEN
+			deriveOutline(status, info)
+
+			// THEN
+			assert.Equal(t, test.outline, info.Outline)
+		})
+	}
+}
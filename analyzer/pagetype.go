@@ -0,0 +1,320 @@
+package analyzer
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Minimum confidence (as a percentage) required to report a page type,
+// or otherwise the page is unknown.
+const minPageTypeConfidence = 60
+
+// Keywords found in button texts, titles and headings, or in identifiers of forms
+// (i.e. action, id, name and class attributes), which reveal the purpose of a form.
+var (
+	loginTextRegex          = regexp.MustCompile(`(?i)\b(log ?in|sign ?in)\b`)
+	loginIdentifierRegex    = regexp.MustCompile(`(?i)(log-?in|sign-?in|session)`)
+	signUpTextRegex         = regexp.MustCompile(`(?i)\b(sign ?up|register|create (an |your )?account|join)\b`)
+	signUpIdentifierRegex   = regexp.MustCompile(`(?i)(sign-?up|regist|create-?account)`)
+	resetTextRegex          = regexp.MustCompile(`(?i)\b(forgot|reset|recover)\b`)
+	resetIdentifierRegex    = regexp.MustCompile(`(?i)(forgot|reset|recover)`)
+	checkoutTextRegex       = regexp.MustCompile(`(?i)\b(pay|pay now|place order|check ?out|purchase|buy now)\b`)
+	checkoutIdentifierRegex = regexp.MustCompile(`(?i)(checkout|payment|billing)`)
+	contactTextRegex        = regexp.MustCompile(`(?i)\b(contact|send message|get in touch)\b`)
+	contactIdentifierRegex  = regexp.MustCompile(`(?i)(contact|enquiry|inquiry|feedback)`)
+	searchTextRegex         = regexp.MustCompile(`(?i)\b(search results?|results for)\b`)
+	softErrorTextRegex      = regexp.MustCompile(`(?i)(\b404\b|not found|(does not|doesn't|no longer) exists?|no longer available)`)
+
+	searchNameRegex  = regexp.MustCompile(`(?i)^(q|s|query|search|keywords?|term)$`)
+	cardNameRegex    = regexp.MustCompile(`(?i)(card.?number|cc.?num|cvv|cvc|security.?code|expir)`)
+	messageNameRegex = regexp.MustCompile(`(?i)(message|subject|comment|enquiry|inquiry)`)
+	termsNameRegex   = regexp.MustCompile(`(?i)(terms|agree|consent|tos)`)
+	personNameRegex  = regexp.MustCompile(`(?i)(first.?name|last.?name|full.?name|given.?name|family.?name)`)
+)
+
+// collectPageSignals collects the meta tags and other elements of the page, which are used
+// along with the forms to identify the type of the page.
+func collectPageSignals(token *html.Token, status *parsingState) {
+	if token.Type == html.EndTagToken {
+		switch token.Data {
+		case "h1":
+			if status.inH1 {
+				status.inH1, status.h1Captured = false, true
+			}
+		case "p":
+			status.paragraphDepth = max(status.paragraphDepth-1, 0)
+		}
+		return
+	}
+
+	attrs := attrsOf(token)
+	if strings.EqualFold(attrs["role"], "search") {
+		status.hasSearch = true
+	}
+
+	switch token.Data {
+	case "meta":
+		key := attrs["name"]
+		if key == "" {
+			key = attrs["property"]
+		}
+		if key != "" {
+			status.metas[strings.ToLower(key)] = attrs["content"]
+		}
+	case "h1":
+		if token.Type == html.StartTagToken && !status.h1Captured {
+			status.inH1 = true
+		}
+	case "article":
+		status.hasArticle = true
+	case "search":
+		status.hasSearch = true
+	case "p":
+		if token.Type == html.StartTagToken {
+			status.paragraphDepth++
+		}
+	}
+}
+
+// buttonMatching returns the first button text matching the given regex, if any.
+func (f *formState) buttonMatching(regex *regexp.Regexp) (string, bool) {
+	for _, button := range f.buttons {
+		if button.Text != "" && regex.MatchString(button.Text) {
+			return button.Text, true
+		}
+	}
+	return "", false
+}
+
+// pageClassifier accumulates the weights (as percentages) of signals supporting each page type.
+type pageClassifier struct {
+	scores  map[string]int
+	signals map[string][]string
+}
+
+// add adds the weight of the given signal to the page type, only once per signal.
+func (c *pageClassifier) add(pageType string, weight int, signal string) {
+	if slices.Contains(c.signals[pageType], signal) {
+		return
+	}
+	c.scores[pageType] += weight
+	c.signals[pageType] = append(c.signals[pageType], signal)
+}
+
+// derivePageType identifies the type of the page using a set of rules matching the structure
+// of forms, names and autocomplete attributes of inputs, texts of buttons, headings and meta tags.
+// Each matched rule adds its weight to the confidence of a page type, and the page type with
+// the highest confidence is selected, unless no page type is confident enough.
+func derivePageType(status *parsingState, info *AnalysisData) {
+	c := &pageClassifier{scores: map[string]int{}, signals: map[string][]string{}}
+
+	// a page having only a single password input and a single submit input is
+	// treated as a login form, same as before introducing other signals.
+	if status.inputTypeCounts["password"] == 1 && status.inputTypeCounts["submit"] == 1 {
+		c.add(LoginForm, 60, "single password input with a single submit input")
+	}
+	for _, form := range status.forms {
+		classifyForm(c, form)
+	}
+	classifyContent(c, status, info)
+
+	info.PageType = Unknown
+	best := 0
+	// types are checked in the declared order, so that ties are resolved consistently.
+	for _, pageType := range []string{LoginForm, SignUpForm, PasswordResetForm, CheckoutForm, ContactForm, SearchPage, ArticlePage, SoftErrorPage} {
+		if score := min(c.scores[pageType], 100); score >= minPageTypeConfidence && score > best {
+			best = score
+			info.PageType, info.PageTypeConfidence, info.PageTypeSignals = pageType, float64(score)/100, c.signals[pageType]
+		}
+	}
+}
+
+func classifyForm(c *pageClassifier, form *formState) {
+	isPassword := func(input inputState) bool { return input.inputType == "password" }
+	isEmail := func(input inputState) bool {
+		return input.inputType == "email" || input.autocomplete == "email" || strings.Contains(strings.ToLower(input.names), "email")
+	}
+	isUsername := func(input inputState) bool {
+		return input.autocomplete == "username" || strings.Contains(strings.ToLower(input.names), "user")
+	}
+	hasAutocomplete := func(prefixes ...string) func(input inputState) bool {
+		return func(input inputState) bool {
+			for _, prefix := range prefixes {
+				for _, token := range strings.Fields(input.autocomplete) {
+					if strings.HasPrefix(token, prefix) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+	}
+	nameMatches := func(regex *regexp.Regexp) func(input inputState) bool {
+		return func(input inputState) bool { return regex.MatchString(input.names) }
+	}
+	passwords := form.countInputs(isPassword)
+	textInputs := form.countInputs(func(input inputState) bool {
+		return input.inputType == "text" || input.inputType == "email" || input.inputType == "tel"
+	})
+
+	// login
+	if passwords == 1 {
+		c.add(LoginForm, 30, "form with a single password input")
+		if form.hasInput(isEmail) || form.hasInput(isUsername) {
+			c.add(LoginForm, 10, "username or email input along with the password")
+		}
+	}
+	if form.hasInput(hasAutocomplete("current-password")) {
+		c.add(LoginForm, 50, "password input with autocomplete=current-password")
+	}
+	if text, ok := form.buttonMatching(loginTextRegex); ok && passwords > 0 {
+		c.add(LoginForm, 40, "button text '"+text+"'")
+	}
+	if loginIdentifierRegex.MatchString(form.identifiers) && passwords > 0 {
+		c.add(LoginForm, 30, "form identified as a login form")
+	}
+
+	// sign up
+	if passwords >= 2 {
+		c.add(SignUpForm, 40, "form with a password confirmation input")
+	}
+	if form.hasInput(hasAutocomplete("new-password")) && passwords > 0 && (form.hasInput(isEmail) || form.hasInput(isUsername)) {
+		c.add(SignUpForm, 50, "password input with autocomplete=new-password")
+	}
+	if form.hasInput(nameMatches(personNameRegex)) || form.hasInput(hasAutocomplete("given-name", "family-name", "name")) {
+		c.add(SignUpForm, 10, "person name input")
+	}
+	if form.hasInput(func(input inputState) bool {
+		return input.inputType == "checkbox" && termsNameRegex.MatchString(input.names)
+	}) {
+		c.add(SignUpForm, 30, "checkbox to agree to the terms")
+	}
+	if text, ok := form.buttonMatching(signUpTextRegex); ok {
+		c.add(SignUpForm, 50, "button text '"+text+"'")
+	}
+	if signUpIdentifierRegex.MatchString(form.identifiers) {
+		c.add(SignUpForm, 30, "form identified as a sign up form")
+	}
+
+	// password reset
+	if passwords == 0 && textInputs == 1 && form.hasInput(isEmail) {
+		if text, ok := form.buttonMatching(resetTextRegex); ok {
+			c.add(PasswordResetForm, 60, "single email input with button text '"+text+"'")
+		} else if resetIdentifierRegex.MatchString(form.identifiers) {
+			c.add(PasswordResetForm, 60, "single email input in a form identified as a password reset form")
+		}
+	}
+	if passwords == 2 && textInputs == 0 && form.hasInput(hasAutocomplete("new-password")) {
+		c.add(PasswordResetForm, 50, "new password input with a confirmation and no other inputs")
+	}
+
+	// checkout
+	if form.hasInput(hasAutocomplete("cc-")) {
+		c.add(CheckoutForm, 60, "payment card input with autocomplete=cc-*")
+	}
+	if form.hasInput(nameMatches(cardNameRegex)) {
+		c.add(CheckoutForm, 40, "payment card input")
+	}
+	if form.hasInput(hasAutocomplete("shipping", "billing", "street-address", "address-line", "postal-code")) {
+		c.add(CheckoutForm, 20, "address input")
+	}
+	if text, ok := form.buttonMatching(checkoutTextRegex); ok {
+		c.add(CheckoutForm, 40, "button text '"+text+"'")
+	}
+	if checkoutIdentifierRegex.MatchString(form.identifiers) {
+		c.add(CheckoutForm, 30, "form identified as a checkout form")
+	}
+
+	// contact
+	if form.hasInput(func(input inputState) bool { return input.element == "textarea" }) && passwords == 0 {
+		c.add(ContactForm, 30, "form with a text area")
+		if form.hasInput(isEmail) {
+			c.add(ContactForm, 20, "email input along with the text area")
+		}
+	}
+	if form.hasInput(nameMatches(messageNameRegex)) {
+		c.add(ContactForm, 20, "message or subject input")
+	}
+	if text, ok := form.buttonMatching(contactTextRegex); ok {
+		c.add(ContactForm, 30, "button text '"+text+"'")
+	}
+	if contactIdentifierRegex.MatchString(form.identifiers) {
+		c.add(ContactForm, 30, "form identified as a contact form")
+	}
+
+	// search boxes are found in many pages, hence they are weak signals by themselves.
+	if form.hasInput(func(input inputState) bool { return input.inputType == "search" }) {
+		c.add(SearchPage, 20, "search input")
+	}
+	if form.method != "post" && form.hasInput(func(input inputState) bool {
+		return slices.ContainsFunc(strings.Fields(input.names), searchNameRegex.MatchString)
+	}) {
+		c.add(SearchPage, 20, "query input in a get form")
+	}
+}
+
+func classifyContent(c *pageClassifier, status *parsingState, info *AnalysisData) {
+	title := strings.TrimSpace(info.Title)
+	h1 := strings.Join(strings.Fields(status.h1Text.String()), " ")
+
+	// search
+	if status.hasSearch {
+		c.add(SearchPage, 10, "search landmark")
+	}
+	if u, err := url.Parse(info.SourceUrl); err == nil {
+		for key, values := range u.Query() {
+			if searchNameRegex.MatchString(key) && len(values) > 0 && values[0] != "" {
+				c.add(SearchPage, 40, "search query parameter '"+key+"' in the url")
+				break
+			}
+		}
+	}
+	if searchTextRegex.MatchString(title) || searchTextRegex.MatchString(h1) {
+		c.add(SearchPage, 40, "title or heading mentions search results")
+	}
+
+	// article
+	if strings.EqualFold(status.metas["og:type"], "article") {
+		c.add(ArticlePage, 50, "meta og:type=article")
+	}
+	if status.metas["article:published_time"] != "" {
+		c.add(ArticlePage, 30, "meta article:published_time")
+	}
+	if status.metas["author"] != "" {
+		c.add(ArticlePage, 10, "meta author")
+	}
+	if status.hasArticle {
+		c.add(ArticlePage, 30, "article element")
+	}
+	if status.paragraphWords >= 300 {
+		c.add(ArticlePage, 20, "long text in paragraphs")
+	}
+
+	// soft errors return a successful status code, while the content says otherwise.
+	if softErrorTextRegex.MatchString(title) {
+		c.add(SoftErrorPage, 60, "title '"+title+"'")
+	}
+	if softErrorTextRegex.MatchString(h1) {
+		c.add(SoftErrorPage, 40, "heading '"+h1+"'")
+	}
+	if strings.Contains(strings.ToLower(status.metas["robots"]), "noindex") {
+		c.add(SoftErrorPage, 10, "meta robots=noindex")
+	}
+
+	// titles and headings of form pages
+	for _, text := range []string{title, h1} {
+		if resetTextRegex.MatchString(text) && strings.Contains(strings.ToLower(text), "password") {
+			c.add(PasswordResetForm, 30, "title or heading mentions resetting the password")
+		} else if signUpTextRegex.MatchString(text) {
+			c.add(SignUpForm, 20, "title or heading mentions signing up")
+		} else if contactTextRegex.MatchString(text) {
+			c.add(ContactForm, 30, "title or heading mentions contacting")
+		} else if checkoutTextRegex.MatchString(text) {
+			c.add(CheckoutForm, 20, "title or heading mentions checkout")
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivePageType(t *testing.T) {
+	testcases := map[string]struct {
+		url        string
+		content    string
+		pageType   string
+		confidence float64
+		signals    []string
+	}{
+		"Login Form With Autocomplete": {
+			content: `<form action="/session" method="post">
+				<input type="email" name="email" autocomplete="username">
+				<input type="password" name="pwd" autocomplete="current-password">
+				<button>Sign in</button>
+				<button type="button">Show password</button>
+			</form>`,
+			pageType:   LoginForm,
+			confidence: 1,
+			signals: []string{
+				"form with a single password input",
+				"username or email input along with the password",
+				"password input with autocomplete=current-password",
+				"button text 'Sign in'",
+				"form identified as a login form",
+			},
+		},
+		"Sign Up Form": {
+			content: `<h1>Create your account</h1>
+				<form id="register">
+					<input name="first_name"><input name="email" type="email">
+					<input type="password" name="password" autocomplete="new-password">
+					<input type="password" name="confirm">
+					<input type="checkbox" name="accept_terms">
+					<input type="submit" value="Sign up">
+				</form>`,
+			pageType:   SignUpForm,
+			confidence: 1,
+			signals: []string{
+				"form with a password confirmation input",
+				"password input with autocomplete=new-password",
+				"person name input",
+				"checkbox to agree to the terms",
+				"button text 'Sign up'",
+				"form identified as a sign up form",
+				"title or heading mentions signing up",
+			},
+		},
+		"Password Reset Form": {
+			content: `<title>Forgot your password?</title>
+				<form><input type="email" name="email"><button>Send reset link</button></form>`,
+			pageType:   PasswordResetForm,
+			confidence: 0.9,
+			signals: []string{
+				"single email input with button text 'Send reset link'",
+				"title or heading mentions resetting the password",
+			},
+		},
+		"Checkout Form": {
+			content: `<form action="/checkout/pay" method="post">
+				<input name="address" autocomplete="shipping street-address">
+				<input name="card" autocomplete="cc-number"><input name="cvc">
+				<button>Place order</button>
+			</form>`,
+			pageType:   CheckoutForm,
+			confidence: 1,
+			signals: []string{
+				"payment card input with autocomplete=cc-*",
+				"payment card input",
+				"address input",
+				"button text 'Place order'",
+				"form identified as a checkout form",
+			},
+		},
+		"Contact Form": {
+			content:    `<form><input type="email" name="email"><textarea name="message"></textarea><button>Send</button></form>`,
+			pageType:   ContactForm,
+			confidence: 0.7,
+			signals:    []string{"form with a text area", "email input along with the text area", "message or subject input"},
+		},
+		"Search Page": {
+			url: "https://www.linklens.com/search?q=crawler",
+			content: `<title>Search results for crawler</title>
+				<form role="search" method="get"><input type="search" name="q"></form>`,
+			pageType:   SearchPage,
+			confidence: 1,
+			signals: []string{
+				"search input",
+				"query input in a get form",
+				"search landmark",
+				"search query parameter 'q' in the url",
+				"title or heading mentions search results",
+			},
+		},
+		"Search Box Only": {
+			content:  `<form role="search"><input type="search" name="q"></form><p>Welcome</p>`,
+			pageType: Unknown,
+		},
+		"Article Page": {
+			content: `<head><meta property="og:type" content="article"><meta name="author" content="Jane"></head>
+				<body><article><h1>How links break</h1><p>` + strings.Repeat("word ", 300) + `</p></article></body>`,
+			pageType:   ArticlePage,
+			confidence: 1,
+			signals:    []string{"meta og:type=article", "meta author", "article element", "long text in paragraphs"},
+		},
+		"Soft Error Page": {
+			content:    `<title>Page Not Found</title><meta name="robots" content="noindex"><h1>Oops! 404</h1>`,
+			pageType:   SoftErrorPage,
+			confidence: 1,
+			signals:    []string{"title 'Page Not Found'", "heading 'Oops! 404'", "meta robots=noindex"},
+		},
+		"Plain Page": {
+			content:  `<title>Home</title><h1>Welcome</h1><p>Hello world</p>`,
+			pageType: Unknown,
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			url := test.url
+			if url == "" {
+				url = "https://www.linklens.com/page"
+			}
+			info := NewAnalysis(url)
+			status, err := parseHtmlContent(strings.NewReader(test.content), info, builtins()...)
+			assert.NoError(t, err)
+
+			// WHEN
+			derivePageType(status, info)
+
+			// THEN
+			assert.Equal(t, test.pageType, info.PageType)
+			assert.Equal(t, test.confidence, info.PageTypeConfidence)
+			assert.Equal(t, test.signals, info.PageTypeSignals)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Maximum length of a data uri recorded as a resource, as inline images may be megabytes long.
+const maxDataUriLength = 64
+
+// collectResources collects the resources loaded by the page, such as images, scripts,
+// stylesheets, icons, media and embedded frames, along with their types.
+func collectResources(token *html.Token, status *parsingState) {
+	if token.Type == html.EndTagToken {
+		if token.Data == status.mediaElement {
+			status.mediaElement = ""
+		}
+		return
+	}
+
+	attrs := attrsOf(token)
+	switch token.Data {
+	case "img":
+		status.addResource(attrs["src"], ImageResource)
+		status.addSrcset(attrs["srcset"], ImageResource)
+	case "picture":
+		status.mediaElement = token.Data
+	case "video", "audio":
+		resourceType := VideoResource
+		if token.Data == "audio" {
+			resourceType = AudioResource
+		}
+		status.addResource(attrs["src"], resourceType)
+		status.addResource(attrs["poster"], ImageResource)
+		if token.Type == html.StartTagToken {
+			status.mediaElement = token.Data
+		}
+	case "source":
+		switch status.mediaElement {
+		case "video":
+			status.addResource(attrs["src"], VideoResource)
+		case "audio":
+			status.addResource(attrs["src"], AudioResource)
+		default:
+			status.addSrcset(attrs["srcset"], ImageResource)
+		}
+	case "script":
+		status.addResource(attrs["src"], ScriptResource)
+	case "link":
+		if resourceType, ok := linkResourceType(attrs["rel"]); ok {
+			status.addResource(attrs["href"], resourceType)
+		}
+	case "iframe":
+		status.addResource(attrs["src"], FrameResource)
+	case "object":
+		status.addResource(attrs["data"], ObjectResource)
+	case "embed":
+		status.addResource(attrs["src"], ObjectResource)
+	}
+}
+
+// linkResourceType returns the type of the resource loaded by a link element having the
+// given rel attribute, or false if the link does not load a resource. (e.g. canonical links)
+func linkResourceType(rel string) (string, bool) {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		switch value {
+		case "stylesheet":
+			return StylesheetResource, true
+		case "icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon":
+			return IconResource, true
+		case "preload", "modulepreload", "prefetch":
+			return PreloadResource, true
+		}
+	}
+	return "", false
+}
+
+// addResource records the given resource url, keeping the type it was first found with.
+func (status *parsingState) addResource(href, resourceType string) {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return
+	}
+	href = shortenDataUri(href)
+	if _, exists := status.resources[href]; !exists {
+		status.resources[href] = resourceType
+	}
+}
+
+// shortenDataUri returns the given data uri cut down to the maximum length, followed by the
+// checksum of the whole uri, so that different contents are still counted separately.
+// Other urls are returned as is. e.g. data:image/png;base64,iVBORw0KGgo...#8f3a1c2e
+func shortenDataUri(href string) string {
+	if !strings.HasPrefix(strings.ToLower(href), "data:") || utf8.RuneCountInString(href) <= maxDataUriLength {
+		return href
+	}
+	return fmt.Sprintf("%s...#%08x", string([]rune(href)[:maxDataUriLength]), crc32.ChecksumIEEE([]byte(href)))
+}
+
+// addSrcset records all image candidates of the given srcset attribute.
+// e.g. "small.jpg 480w, large.jpg 1080w" or "logo.png, logo@2x.png 2x"
+func (status *parsingState) addSrcset(srcset, resourceType string) {
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			status.addResource(fields[0], resourceType)
+		}
+	}
+}
+
+// attrsOf returns the attributes of the given token mapped by their names.
+func attrsOf(token *html.Token) map[string]string {
+	attrs := map[string]string{}
+	for _, v := range token.Attr {
+		attrs[v.Key] = v.Val
+	}
+	return attrs
+}
+
+// crawlResources verifies the given resources using the crawler, if it supports verifying resources.
+// Otherwise, resources are only counted by their types without verifying.
+func crawlResources(ctx context.Context, a *Analyzer, crawler Crawler, pageUrl, baseUrl string, resources map[string]string) *ResourceStats {
+	if resourceCrawler, ok := crawler.(ResourceCrawler); ok {
+		return resourceCrawler.CrawlResources(ctx, a, pageUrl, baseUrl, resources)
+	}
+
+	config := &CrawlConfig{}
+	stats := &ResourceStats{}
+	for _, href := range sortedLinks(resources) {
+		stats.add(ResourceStatus{Type: resources[href], LinkStatus: config.newLinkStatus(href, "", pageUrl, baseUrl)})
+	}
+	return stats
+}
+
+// CrawlResources verifies the given resources found in the page having the given base url,
+// and returns their statistics broken down by the resource types. Resources are verified
+// same as links, hence the crawl configurations such as maximum links apply to them too.
+func (c *CrawlConfig) CrawlResources(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, resources map[string]string) *ResourceStats {
+	pendingResources := c.linksToVerify(pageUrl, baseUrl, resources)
+	slog.Info("Starting crawling for resources...", "site", pageUrl, "pending#", len(pendingResources))
+
+	verifiedResources := map[string]LinkStatus{}
+	for status := range c.verifyLinks(ctx, a, pageUrl, baseUrl, pendingResources, nil) {
+		verifiedResources[status.Href] = status
+	}
+
+	stats := &ResourceStats{}
+	for _, href := range sortedLinks(resources) {
+		status, ok := verifiedResources[href]
+		if !ok {
+			status = c.newLinkStatus(href, "", pageUrl, baseUrl)
+		}
+		stats.add(ResourceStatus{Type: resources[href], LinkStatus: status})
+	}
+
+	if len(verifiedResources) < len(pendingResources) {
+		slog.Info("Crawling cancelled before verifying all resources!", "site", pageUrl, "verified#", len(verifiedResources))
+		stats.Incomplete = true
+	}
+	return stats
+}
+
+// add records the given resource under its type, and counts it as broken if verified
+// to be invalid. Resources blocked by robots.txt are not broken.
+func (s *ResourceStats) add(resource ResourceStatus) {
+	if s.Types == nil {
+		s.Types = map[string]ResourceTypeStats{}
+	}
+	typeStats := s.Types[resource.Type]
+	typeStats.Count++
+	s.ResourceCount++
+	if resource.Verified && !resource.IsValid {
+		slog.Info("Broken resource found!", "url", resource.Url, "type", resource.Type, "status", resource.StatusCode)
+		typeStats.BrokenCount++
+		typeStats.BrokenResources = append(typeStats.BrokenResources, resource.displayUrl())
+		s.BrokenResourceCount++
+	}
+	s.Types[resource.Type] = typeStats
+	s.Resources = append(s.Resources, resource)
+}
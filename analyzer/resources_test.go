@@ -0,0 +1,150 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectResources(t *testing.T) {
+	// GIVEN
+	content := `<html><head>
+		<link rel="stylesheet" href="/css/site.css">
+		<link rel="icon" href="/favicon.ico">
+		<link rel="preload" href="/fonts/inter.woff2" as="font">
+		<link rel="canonical" href="https://www.linklens.com/page">
+		<script src="/js/app.js"></script>
+		<script>console.log("inline")</script>
+	</head><body>
+		<img src="/img/logo.png" srcset="/img/logo.png 1x, /img/logo@2x.png 2x">
+		<picture><source srcset="/img/hero.webp"><img src="/img/hero.jpg"></picture>
+		<video src="/media/intro.mp4" poster="/img/poster.jpg"><source src="/media/intro.webm"></video>
+		<audio><source src="/media/theme.ogg"></audio>
+		<iframe src="https://www.youtube.com/embed/x"></iframe>
+		<object data="/docs/manual.pdf"></object>
+		<embed src="/media/widget.swf">
+	</body></html>`
+	info := NewAnalysis("https://www.linklens.com/page")
+
+	// WHEN
+	status, err := parseHtmlContent(strings.NewReader(content), info, builtins()...)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/css/site.css":                   StylesheetResource,
+		"/favicon.ico":                    IconResource,
+		"/fonts/inter.woff2":              PreloadResource,
+		"/js/app.js":                      ScriptResource,
+		"/img/logo.png":                   ImageResource,
+		"/img/logo@2x.png":                ImageResource,
+		"/img/hero.webp":                  ImageResource,
+		"/img/hero.jpg":                   ImageResource,
+		"/media/intro.mp4":                VideoResource,
+		"/img/poster.jpg":                 ImageResource,
+		"/media/intro.webm":               VideoResource,
+		"/media/theme.ogg":                AudioResource,
+		"https://www.youtube.com/embed/x": FrameResource,
+		"/docs/manual.pdf":                ObjectResource,
+		"/media/widget.swf":               ObjectResource,
+	}, status.resources)
+}
+
+func TestCollectResources_DataUris(t *testing.T) {
+	// GIVEN
+	large := "data:image/png;base64," + strings.Repeat("iVBORw0KGgo", 10000)
+	content := `<html><body>
+		<img src="` + large + `A"><img src="` + large + `B"><img src="` + large + `A">
+		<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+	</body></html>`
+	info := NewAnalysis("https://www.linklens.com/page")
+
+	// WHEN
+	status, err := parseHtmlContent(strings.NewReader(content), info, builtins()...)
+
+	// THEN
+	assert.NoError(t, err)
+	// different contents are counted separately, without keeping the whole uri.
+	assert.Len(t, status.resources, 3)
+	assert.Equal(t, ImageResource, status.resources["data:image/gif;base64,R0lGODlhAQABAAAAACw="])
+	for href := range status.resources {
+		assert.LessOrEqual(t, len(href), maxDataUriLength+12)
+		assert.True(t, strings.HasPrefix(href, "data:image/"), href)
+	}
+}
+
+// crawler verifying links only, without verifying resources.
+type linksOnlyCrawler struct {
+	crawler OneDepthCrawler
+}
+
+func (c *linksOnlyCrawler) Crawl(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, links map[string]string) *LinkStats {
+	return c.crawler.Crawl(ctx, a, pageUrl, baseUrl, links)
+}
+
+func TestAnalyzeUrl_Resources(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="stylesheet" href="/site.css"><script src="/missing.js"></script></head>
+				<body><img src="/logo.png"><img src="/missing.png"><a href="/about">About</a></body></html>`)
+		case "/site.css", "/logo.png", "/about":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	testcases := map[string]struct {
+		crawler     Crawler
+		brokenCount int
+		typeStats   map[string]ResourceTypeStats
+		verified    bool
+	}{
+		"Verifies Resources": {
+			crawler:     &OneDepthCrawler{},
+			brokenCount: 2,
+			typeStats: map[string]ResourceTypeStats{
+				ImageResource:      {Count: 2, BrokenCount: 1, BrokenResources: []string{server.URL + "/missing.png"}},
+				ScriptResource:     {Count: 1, BrokenCount: 1, BrokenResources: []string{server.URL + "/missing.js"}},
+				StylesheetResource: {Count: 1},
+			},
+			verified: true,
+		},
+		"Crawler Without Resource Support": {
+			crawler: &linksOnlyCrawler{},
+			typeStats: map[string]ResourceTypeStats{
+				ImageResource:      {Count: 2},
+				ScriptResource:     {Count: 1},
+				StylesheetResource: {Count: 1},
+			},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			info, err := AnalyzeUrl(context.Background(), server.URL+"/", test.crawler)
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, 1, info.LinkStats.InternalLinkCount)
+			assert.Equal(t, 0, info.LinkStats.InvalidLinkCount)
+			assert.Equal(t, 4, info.ResourceStats.ResourceCount)
+			assert.Equal(t, test.brokenCount, info.ResourceStats.BrokenResourceCount)
+			assert.Equal(t, test.typeStats, info.ResourceStats.Types)
+			assert.False(t, info.ResourceStats.Incomplete)
+			for _, resource := range info.ResourceStats.Resources {
+				assert.Equal(t, test.verified, resource.Verified, resource.Href)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	defaultRetryStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	defaultRetryErrorCategories = []string{TimeoutError, ConnectionError}
+)
+
+func (p RetryPolicy) maxAttempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+func (p RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff <= 0 {
+		return DefaultMaxRetryBackoff
+	}
+	return p.MaxBackoff
+}
+
+// backoff returns the delay before the given retry (starting from 1), which is doubled
+// on each retry. A random jitter of up to half of the delay is subtracted, so that
+// concurrent checks failed at the same time do not retry at the same time.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.Backoff
+	if delay <= 0 {
+		delay = DefaultRetryBackoff
+	}
+	for i := 1; i < retry && delay < p.maxBackoff(); i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.maxBackoff())
+	return delay - time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryable returns true if a check failed with the given status code or error can be retried.
+func (p RetryPolicy) retryable(statusCode int, err error) bool {
+	if err != nil {
+		categories := p.ErrorCategories
+		if categories == nil {
+			categories = defaultRetryErrorCategories
+		}
+		return slices.Contains(categories, errorCategoryOf(err))
+	}
+
+	statusCodes := p.StatusCodes
+	if statusCodes == nil {
+		statusCodes = defaultRetryStatusCodes
+	}
+	return slices.Contains(statusCodes, statusCode)
+}
+
+// findUrlStatusWithRetry returns the status code and the headers of the given url, retrying
+// transient failures according to the retry policy. Requests are sent respecting the rate
+// limit of the host, and the host is paused when it asks to retry later in a 429 or 503.
+// The timeout applies to each attempt, excluding the time waiting for the rate limit and backoff.
+func (a *Analyzer) findUrlStatusWithRetry(ctx context.Context, checkUrl string, timeout time.Duration) (int, http.Header, error) {
+	policy := a.options.Retry
+	for attempt := 1; ; attempt++ {
+		if err := a.rates.wait(ctx, checkUrl); err != nil {
+			return 0, nil, err
+		}
+		attemptCtx, cancel := withTimeout(ctx, timeout)
+		statusCode, header, err := a.findUrlStatus(attemptCtx, checkUrl)
+		cancel()
+
+		retryAfter, hasRetryAfter := time.Duration(0), false
+		if statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable {
+			retryAfter, hasRetryAfter = parseRetryAfter(header.Get("Retry-After"))
+		}
+		if hasRetryAfter {
+			a.rates.pause(checkUrl, min(retryAfter, policy.maxBackoff()))
+		}
+
+		if attempt >= policy.maxAttempts() || ctx.Err() != nil || !policy.retryable(statusCode, err) {
+			return statusCode, header, err
+		} else if hasRetryAfter && retryAfter > policy.maxBackoff() {
+			slog.Debug("Not retrying, since the host asks to retry too late", "url", checkUrl, "retryAfter", retryAfter)
+			return statusCode, header, err
+		}
+
+		delay := policy.backoff(attempt)
+		if hasRetryAfter {
+			// the host is already paused until then.
+			delay = 0
+		}
+		slog.Debug("Retrying the link check", "url", checkUrl, "attempt", attempt, "status", statusCode, "error", err, "delay", delay)
+		if !sleep(ctx, delay) {
+			return statusCode, header, err
+		}
+	}
+}
+
+// parseRetryAfter returns the delay asked by the given Retry-After header value, which is
+// either a number of seconds or a http date. It returns false if the value is invalid.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleep blocks for the given duration, or returns false if the context is cancelled before.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
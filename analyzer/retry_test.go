@@ -0,0 +1,207 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLink_Retry(t *testing.T) {
+	testcases := map[string]struct {
+		responses  []int
+		retryAfter string
+		policy     RetryPolicy
+		attempts   int
+		valid      bool
+		statusCode int
+		minElapsed time.Duration
+	}{
+		"Recovers From Transient Error": {
+			responses:  []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			policy:     RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+			attempts:   3,
+			valid:      true,
+			statusCode: http.StatusOK,
+		},
+		"Gives Up After Max Attempts": {
+			responses:  []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			policy:     RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+			attempts:   2,
+			valid:      false,
+			statusCode: http.StatusServiceUnavailable,
+		},
+		"Not Retryable Status": {
+			responses:  []int{http.StatusNotFound, http.StatusOK},
+			policy:     RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+			attempts:   1,
+			valid:      false,
+			statusCode: http.StatusNotFound,
+		},
+		"Custom Retryable Status": {
+			responses:  []int{http.StatusNotFound, http.StatusOK},
+			policy:     RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, StatusCodes: []int{http.StatusNotFound}},
+			attempts:   2,
+			valid:      true,
+			statusCode: http.StatusOK,
+		},
+		"Retries Disabled": {
+			responses:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			attempts:   1,
+			valid:      false,
+			statusCode: http.StatusServiceUnavailable,
+		},
+		"Honours Retry After": {
+			responses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter: "1",
+			policy:     RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+			attempts:   2,
+			valid:      true,
+			statusCode: http.StatusOK,
+			minElapsed: time.Second,
+		},
+		"Retry After Too Long": {
+			responses:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter: "120",
+			policy:     RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Second},
+			attempts:   1,
+			valid:      false,
+			statusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			var mu sync.Mutex
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/robots.txt" {
+					http.NotFound(w, r)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.responses[attempts])
+				attempts++
+			}))
+			defer server.Close()
+			a := NewAnalyzer(Options{Retry: test.policy})
+
+			// WHEN
+			startedAt := time.Now()
+			status := a.checkLink(context.Background(), server.URL+"/flaky", 0, nil)
+
+			// THEN
+			assert.Equal(t, test.valid, status.IsValid)
+			assert.Equal(t, test.statusCode, status.StatusCode)
+			assert.Equal(t, test.attempts, attempts)
+			assert.GreaterOrEqual(t, time.Since(startedAt), test.minElapsed)
+		})
+	}
+}
+
+func TestCheckLink_RetryCancelled(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	a := NewAnalyzer(Options{IgnoreRobots: true, Retry: RetryPolicy{MaxAttempts: 5, Backoff: time.Minute}})
+
+	// link timeout applies only to the requests, hence the backoff is cut short by cancelling.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// WHEN
+	startedAt := time.Now()
+	status := a.checkLink(ctx, server.URL+"/flaky", time.Minute, nil)
+
+	// THEN
+	assert.False(t, status.IsValid)
+	assert.Equal(t, http.StatusServiceUnavailable, status.StatusCode)
+	assert.Less(t, time.Since(startedAt), 5*time.Second)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for retry, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second} {
+		delay := policy.backoff(retry)
+		assert.LessOrEqual(t, delay, expected)
+		assert.GreaterOrEqual(t, delay, expected/2)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	testcases := map[string]struct {
+		value    string
+		delay    time.Duration
+		expected bool
+	}{
+		"Seconds":   {value: "30", delay: 30 * time.Second, expected: true},
+		"Http Date": {value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), delay: time.Hour, expected: true},
+		"Past Date": {value: "Wed, 21 Oct 2015 07:28:00 GMT", delay: 0, expected: true},
+		"Empty":     {value: "", expected: false},
+		"Invalid":   {value: "soon", expected: false},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			delay, ok := parseRetryAfter(test.value)
+
+			// THEN
+			assert.Equal(t, test.expected, ok)
+			assert.InDelta(t, test.delay, delay, float64(2*time.Second))
+		})
+	}
+}
+
+func TestNewAnalyzer_HostRateLimit(t *testing.T) {
+	// GIVEN
+	var mu sync.Mutex
+	var requestedAt []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requestedAt = append(requestedAt, time.Now())
+	}))
+	defer server.Close()
+	a := NewAnalyzer(Options{IgnoreRobots: true, HostRateLimit: 20})
+	links := map[string]string{"/a": "", "/b": "", "/c": "", "/d": ""}
+
+	// WHEN
+	stats := (&OneDepthCrawler{}).Crawl(context.Background(), a, server.URL, server.URL, links)
+
+	// THEN
+	assert.Equal(t, 0, stats.InvalidLinkCount)
+	assert.Len(t, requestedAt, 4)
+	assert.GreaterOrEqual(t, requestedAt[3].Sub(requestedAt[0]), 140*time.Millisecond)
+}
+
+func TestNewAnalyzer_HostRateLimitWithLinkTimeout(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	// links wait up to 2 seconds in total for the rate limit, which is longer than the link timeout.
+	a := NewAnalyzer(Options{IgnoreRobots: true, HostRateLimit: 2})
+	crawler := &OneDepthCrawler{CrawlConfig: CrawlConfig{LinkTimeout: 1200 * time.Millisecond}}
+	links := map[string]string{"/1": "", "/2": "", "/3": "", "/4": "", "/5": ""}
+
+	// WHEN
+	stats := crawler.Crawl(context.Background(), a, server.URL, server.URL, links)
+
+	// THEN
+	assert.Equal(t, 0, stats.InvalidLinkCount)
+	for _, link := range stats.Links {
+		assert.True(t, link.IsValid, link.Href)
+	}
+}
@@ -0,0 +1,336 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Maximum size of a robots.txt parsed, as recommended in RFC 9309.
+	maxRobotsSize = 500 * 1024
+	// Duration to keep the rules of a fetched robots.txt.
+	robotsTTL = 24 * time.Hour
+	// Duration to keep the outcome of a robots.txt which could not be fetched.
+	robotsErrorTTL     = time.Minute
+	robotsFetchTimeout = 10 * time.Second
+	// Maximum crawl delay honoured, so that a site cannot stall the analysis.
+	maxCrawlDelay = 10 * time.Second
+)
+
+// Rules of a robots.txt applicable to a single user agent, as defined in RFC 9309.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// A group of rules applicable to one or more user agents.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+var disallowAll = &robotsRules{rules: []robotsRule{{allow: false, pattern: "/"}}}
+
+// parseRobots parses the given robots.txt content and returns the rules of the group
+// matching to the given user agent. The most specific group matching to the product
+// token of the user agent is selected, or otherwise the group of all user agents (*).
+func parseRobots(content io.Reader, userAgent string) *robotsRules {
+	groups := []*robotsGroup{}
+	var current *robotsGroup
+	inRules := false
+
+	scanner := bufio.NewScanner(io.LimitReader(content, maxRobotsSize))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// consecutive user agent lines share the same group
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// an empty disallow rule allows everything, same as not having the rule.
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = min(time.Duration(seconds*float64(time.Second)), maxCrawlDelay)
+			}
+		}
+	}
+
+	token := productToken(userAgent)
+	bestAgent := ""
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent != "*" && strings.HasPrefix(token, agent) && len(agent) > len(bestAgent) {
+				bestAgent = agent
+			}
+		}
+	}
+	if bestAgent == "" {
+		bestAgent = "*"
+	}
+
+	result := &robotsRules{}
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent == bestAgent {
+				result.rules = append(result.rules, group.rules...)
+				result.crawlDelay = max(result.crawlDelay, group.crawlDelay)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// productToken returns the lower cased name of the crawler in the given user agent.
+// e.g. linklens in LinkLens/1.0 (+https://github.com/isuru89/link-lens)
+func productToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return strings.ToLower(token)
+}
+
+// allows returns true if the given path (including the query) can be crawled.
+// The rule with the longest matching pattern is applied, and allow rules win on ties.
+func (r *robotsRules) allows(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern returns true if the given path matches to the pattern, where
+// the pattern may have * to match any sequence of characters and $ to match the end.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// last part of an anchored pattern must match the end of the path.
+			return len(path)-pos >= len(part) && strings.HasSuffix(path, part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
+
+// robotsCache keeps the robots.txt rules of each site, and the time each site
+// can be requested next, to honour the crawl delay.
+type robotsCache struct {
+	mu     sync.Mutex
+	sites  map[string]*robotsEntry
+	delays map[string]chan time.Time
+}
+
+type robotsEntry struct {
+	ready     chan struct{}
+	rules     *robotsRules
+	expiresAt time.Time
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{sites: map[string]*robotsEntry{}, delays: map[string]chan time.Time{}}
+}
+
+// rulesOf returns the rules of the given site, fetching the robots.txt in the background
+// only if the rules are not cached. Concurrent callers wait for the same fetch.
+// It returns nil if the context is cancelled while waiting.
+func (c *robotsCache) rulesOf(ctx context.Context, site string, fetch func() (*robotsRules, time.Duration)) *robotsRules {
+	c.mu.Lock()
+	entry, ok := c.sites[site]
+	if !ok || entry.expired() {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.sites[site] = entry
+		go func() {
+			rules, ttl := fetch()
+			entry.rules, entry.expiresAt = rules, time.Now().Add(ttl)
+			close(entry.ready)
+		}()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.rules
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (e *robotsEntry) expired() bool {
+	select {
+	case <-e.ready:
+		return time.Now().After(e.expiresAt)
+	default:
+		// still being fetched
+		return false
+	}
+}
+
+// wait blocks until the given site can be requested again after the given delay
+// since the last request. It returns an error if the context is cancelled while waiting.
+func (c *robotsCache) wait(ctx context.Context, site string, delay time.Duration) error {
+	c.mu.Lock()
+	next, ok := c.delays[site]
+	if !ok {
+		// holds the time the site can be requested next, and acts as a lock among requests.
+		next = make(chan time.Time, 1)
+		next <- time.Time{}
+		c.delays[site] = next
+	}
+	c.mu.Unlock()
+
+	var at time.Time
+	select {
+	case at = <-next:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		next <- time.Now().Add(delay)
+		return nil
+	case <-ctx.Done():
+		next <- at
+		return ctx.Err()
+	}
+}
+
+// allowedByRobots returns true if the robots.txt of the site allows the analyzer
+// to request the given url. Sites exempted in options are always allowed.
+func (a *Analyzer) allowedByRobots(ctx context.Context, target string) bool {
+	site, path, ok := a.robotsSiteOf(target)
+	if !ok {
+		return true
+	}
+
+	rules := a.robots.rulesOf(ctx, site, func() (*robotsRules, time.Duration) {
+		return a.fetchRobots(ctx, site)
+	})
+	// when cancelled, the request will be aborted anyway.
+	return rules == nil || rules.allows(path)
+}
+
+// waitForCrawlDelay blocks until the crawl delay of the site of the given url elapses
+// since the last request sent to the same site.
+func (a *Analyzer) waitForCrawlDelay(ctx context.Context, target string) error {
+	site, _, ok := a.robotsSiteOf(target)
+	if !ok {
+		return nil
+	}
+
+	rules := a.robots.rulesOf(ctx, site, func() (*robotsRules, time.Duration) {
+		return a.fetchRobots(ctx, site)
+	})
+	if rules == nil {
+		return ctx.Err()
+	} else if rules.crawlDelay == 0 {
+		return nil
+	}
+	return a.robots.wait(ctx, site, rules.crawlDelay)
+}
+
+// robotsSiteOf returns the site (scheme and host) and the path of the given url,
+// or false if robots.txt does not apply to the url.
+func (a *Analyzer) robotsSiteOf(target string) (string, string, bool) {
+	if a.robots == nil {
+		return "", "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil || !isHttpUrl(u) {
+		return "", "", false
+	}
+	for _, host := range a.options.RobotsExemptHosts {
+		if strings.EqualFold(host, u.Hostname()) {
+			return "", "", false
+		}
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), u.RequestURI(), true
+}
+
+// fetchRobots fetches and parses the robots.txt of the given site, and returns the rules
+// along with the duration to keep them. As in RFC 9309, a missing robots.txt (4xx) allows
+// everything, while a server error (5xx) disallows everything. Connection failures allow
+// everything, so that the links are reported with the actual error.
+func (a *Analyzer) fetchRobots(ctx context.Context, site string) (*robotsRules, time.Duration) {
+	// robots.txt is shared among all callers, hence it is not cancelled with the first caller.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), robotsFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return &robotsRules{}, robotsErrorTTL
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		slog.Info("Unable to fetch robots.txt! Allowing all urls.", "site", site, "error", err)
+		return &robotsRules{}, robotsErrorTTL
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		slog.Warn("Robots.txt is unreachable! Disallowing all urls.", "site", site, "status", resp.StatusCode)
+		return disallowAll, robotsErrorTTL
+	} else if resp.StatusCode >= 400 {
+		return &robotsRules{}, robotsTTL
+	}
+	return parseRobots(resp.Body, a.options.userAgent()), robotsTTL
+}
@@ -0,0 +1,317 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRobots(t *testing.T) {
+	content := `
+# comments are ignored
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: LinkLens
+User-agent: other
+Disallow: /admin # only for link lens
+Allow: /admin/docs
+Crawl-delay: 0.5
+
+User-agent: linklens-extra
+Disallow: /
+`
+	testcases := map[string]struct {
+		userAgent  string
+		allowed    []string
+		disallowed []string
+		crawlDelay time.Duration
+	}{
+		"Matching Agent": {
+			userAgent:  DefaultUserAgent,
+			allowed:    []string{"/", "/private", "/admin/docs/x", "/robots.txt"},
+			disallowed: []string{"/admin", "/admin?x=1", "/administrator"},
+			crawlDelay: 500 * time.Millisecond,
+		},
+		"Fallback To All Agents": {
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64)",
+			allowed:    []string{"/", "/admin", "/private/public/x", "/docs.pdf?x=1"},
+			disallowed: []string{"/private", "/private/x", "/docs.pdf", "/a/b/c.pdf"},
+			crawlDelay: 2 * time.Second,
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			rules := parseRobots(strings.NewReader(content), test.userAgent)
+
+			// THEN
+			assert.Equal(t, test.crawlDelay, rules.crawlDelay)
+			for _, path := range test.allowed {
+				assert.True(t, rules.allows(path), path)
+			}
+			for _, path := range test.disallowed {
+				assert.False(t, rules.allows(path), path)
+			}
+		})
+	}
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	testcases := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"/docs", "/docs/index.html", true},
+		{"/docs", "/doc", false},
+		{"/*/edit", "/pages/1/edit", true},
+		{"/*/edit", "/edit", false},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php?x=1", false},
+		{"/page$", "/page", true},
+		{"/page$", "/pages", false},
+		{"/*", "/", true},
+	}
+
+	for _, test := range testcases {
+		assert.Equal(t, test.matches, matchRobotsPattern(test.pattern, test.path), "%s %s", test.pattern, test.path)
+	}
+}
+
+func TestAnalyzeUrl_Robots(t *testing.T) {
+	// GIVEN
+	var robotsFetches, pageFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsFetches.Add(1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/private":
+			pageFetches.Add(1)
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprint(w, `<html></html>`)
+		default:
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/private">p</a><a href="/public">p</a><a href="/missing-page">m</a></body></html>`)
+		}
+	}))
+	defer server.Close()
+	host, _ := url.Parse(server.URL)
+
+	testcases := map[string]struct {
+		options        Options
+		blockedLinks   []string
+		blockedPageErr bool
+	}{
+		"Respects Robots": {
+			options:        Options{},
+			blockedLinks:   []string{server.URL + "/private"},
+			blockedPageErr: true,
+		},
+		"Ignores Robots": {
+			options: Options{IgnoreRobots: true},
+		},
+		"Exempted Host": {
+			options: Options{RobotsExemptHosts: []string{strings.ToUpper(host.Hostname())}},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			robotsFetches.Store(0)
+			pageFetches.Store(0)
+			a := NewAnalyzer(test.options)
+
+			// WHEN
+			info, err := a.AnalyzeUrl(context.Background(), server.URL+"/", &OneDepthCrawler{})
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.blockedLinks), info.LinkStats.BlockedLinkCount)
+			assert.Equal(t, test.blockedLinks, info.LinkStats.BlockedLinks)
+			assert.Equal(t, 0, info.LinkStats.InvalidLinkCount)
+			for _, link := range info.LinkStats.Links {
+				blocked := link.Href == "/private" && test.blockedPageErr
+				assert.Equal(t, blocked, link.BlockedByRobots, link.Href)
+				assert.Equal(t, !blocked, link.Verified, link.Href)
+			}
+
+			// WHEN
+			_, err = a.AnalyzeUrl(context.Background(), server.URL+"/private", &OneDepthCrawler{})
+
+			// THEN
+			var analysisErr *AnalysisError
+			if test.blockedPageErr {
+				assert.True(t, errors.As(err, &analysisErr))
+				assert.Equal(t, BlockedByRobots, analysisErr.ErrorCode)
+				assert.Equal(t, int32(1), robotsFetches.Load())
+				assert.Equal(t, int32(0), pageFetches.Load())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int32(0), robotsFetches.Load())
+			}
+		})
+	}
+}
+
+func TestAnalyzeUrl_RobotsUnavailable(t *testing.T) {
+	testcases := map[string]struct {
+		statusCode int
+		errorCode  string
+	}{
+		"Missing Robots Allows All":   {statusCode: http.StatusNotFound},
+		"Server Error Disallows All":  {statusCode: http.StatusServiceUnavailable, errorCode: BlockedByRobots},
+		"Forbidden Robots Allows All": {statusCode: http.StatusForbidden},
+		"Gone Robots Allows All":      {statusCode: http.StatusGone},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/robots.txt" {
+					w.WriteHeader(test.statusCode)
+					return
+				}
+				w.Header().Set("content-type", "text/html")
+				fmt.Fprint(w, `<html><title>Page</title></html>`)
+			}))
+			defer server.Close()
+
+			// WHEN
+			info, err := NewAnalyzer(Options{}).AnalyzeUrl(context.Background(), server.URL+"/page", &OneDepthCrawler{})
+
+			// THEN
+			if test.errorCode == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "Page", info.Title)
+			} else {
+				var analysisErr *AnalysisError
+				assert.True(t, errors.As(err, &analysisErr))
+				assert.Equal(t, test.errorCode, analysisErr.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestAnalyzeUrl_CrawlDelay(t *testing.T) {
+	// GIVEN
+	var mu sync.Mutex
+	var requestedAt []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nCrawl-delay: 0.1\n")
+			return
+		}
+		mu.Lock()
+		requestedAt = append(requestedAt, time.Now())
+		mu.Unlock()
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/a">a</a><a href="/b">b</a></body></html>`)
+	}))
+	defer server.Close()
+
+	// WHEN
+	_, err := NewAnalyzer(Options{}).AnalyzeUrl(context.Background(), server.URL+"/", &OneDepthCrawler{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Len(t, requestedAt, 3)
+	for i := 1; i < len(requestedAt); i++ {
+		assert.GreaterOrEqual(t, requestedAt[i].Sub(requestedAt[i-1]), 90*time.Millisecond)
+	}
+}
+
+func TestAnalyzeUrl_CrawlDelayWithLinkTimeout(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nCrawl-delay: 0.1\n")
+			return
+		}
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/d">d</a><a href="/e">e</a></body></html>`)
+	}))
+	defer server.Close()
+	// links wait up to 0.6 seconds in total for the crawl delay, which is longer than the link timeout.
+	crawler := &OneDepthCrawler{CrawlConfig: CrawlConfig{LinkTimeout: 250 * time.Millisecond}}
+
+	// WHEN
+	info, err := NewAnalyzer(Options{}).AnalyzeUrl(context.Background(), server.URL+"/", crawler)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 5, info.LinkStats.InternalLinkCount)
+	assert.Equal(t, 0, info.LinkStats.InvalidLinkCount)
+	for _, link := range info.LinkStats.Links {
+		assert.True(t, link.IsValid, link.Href)
+	}
+}
+
+func TestAnalyzeUrl_RedirectedRobots(t *testing.T) {
+	// GIVEN
+	var mu sync.Mutex
+	var privateFetches atomic.Int32
+	var requestedAt []time.Time
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\nCrawl-delay: 0.1\n")
+		case "/private":
+			privateFetches.Add(1)
+		default:
+			mu.Lock()
+			requestedAt = append(requestedAt, time.Now())
+			mu.Unlock()
+		}
+	}))
+	defer target.Close()
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(w, r)
+		case "/":
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/private">p</a><a href="/a">a</a><a href="/b">b</a></body></html>`)
+		default:
+			http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+		}
+	}))
+	defer source.Close()
+
+	// WHEN
+	info, err := NewAnalyzer(Options{}).AnalyzeUrl(context.Background(), source.URL+"/", &OneDepthCrawler{})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 0, info.LinkStats.InvalidLinkCount)
+	assert.Equal(t, []string{source.URL + "/private"}, info.LinkStats.BlockedLinks)
+	for _, link := range info.LinkStats.Links {
+		blocked := link.Href == "/private"
+		assert.Equal(t, blocked, link.BlockedByRobots, link.Href)
+		assert.Equal(t, !blocked, link.Verified, link.Href)
+		assert.Len(t, link.Redirects, 1, link.Href)
+	}
+	assert.Equal(t, int32(0), privateFetches.Load())
+
+	// redirected urls wait for the crawl delay of their site.
+	assert.Len(t, requestedAt, 2)
+	for i := 1; i < len(requestedAt); i++ {
+		assert.GreaterOrEqual(t, requestedAt[i].Sub(requestedAt[i-1]), 90*time.Millisecond)
+	}
+}
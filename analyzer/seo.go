@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Recommended length of the title in characters, as search engines truncate longer titles.
+const (
+	minTitleLength = 30
+	maxTitleLength = 60
+)
+
+// collectMetadata collects the SEO related metadata of the page, such as meta tags, canonical
+// and hreflang links, along with the counts of titles and descriptions.
+func collectMetadata(token *html.Token, status *parsingState) {
+	if token.Type == html.EndTagToken {
+		if token.Data == "svg" && status.svgDepth > 0 {
+			status.svgDepth--
+		}
+		return
+	}
+
+	attrs := attrsOf(token)
+	switch token.Data {
+	case "svg":
+		if token.Type == html.StartTagToken {
+			status.svgDepth++
+		}
+	case "title":
+		// svg images have their own titles, which are not titles of the page.
+		if status.svgDepth == 0 {
+			status.titleCount++
+		}
+	case "meta":
+		collectMeta(attrs, status)
+	case "link":
+		rels := strings.Fields(strings.ToLower(attrs["rel"]))
+		href := strings.TrimSpace(attrs["href"])
+		if href == "" {
+			return
+		}
+		for _, rel := range rels {
+			if rel == "canonical" && status.metadata.Canonical == "" {
+				status.metadata.Canonical = href
+			} else if rel == "alternate" && attrs["hreflang"] != "" {
+				status.metadata.Alternates = append(status.metadata.Alternates, AlternateLink{Hreflang: attrs["hreflang"], Url: href})
+			}
+		}
+	}
+}
+
+// collectMeta collects a single meta element. When the same meta is repeated, the first one is kept.
+func collectMeta(attrs map[string]string, status *parsingState) {
+	metadata := &status.metadata
+	if charset, ok := attrs["charset"]; ok && metadata.Charset == "" {
+		metadata.Charset = strings.TrimSpace(charset)
+	}
+	// e.g. <meta http-equiv="content-type" content="text/html; charset=utf-8">
+	if strings.EqualFold(attrs["http-equiv"], "content-type") && metadata.Charset == "" {
+		if _, charset, found := strings.Cut(strings.ToLower(attrs["content"]), "charset="); found {
+			metadata.Charset = strings.TrimSpace(charset)
+		}
+	}
+
+	key := attrs["name"]
+	if key == "" {
+		key = attrs["property"]
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	content := strings.TrimSpace(attrs["content"])
+	switch {
+	case key == "description":
+		status.descriptionCount++
+		if status.descriptionCount == 1 {
+			metadata.Description = content
+		}
+	case key == "robots" && metadata.Robots == "":
+		metadata.Robots = content
+	case key == "viewport" && metadata.Viewport == "":
+		metadata.Viewport = content
+	case strings.HasPrefix(key, "og:"):
+		metadata.OpenGraph = putIfAbsent(metadata.OpenGraph, key, content)
+	case strings.HasPrefix(key, "twitter:"):
+		metadata.TwitterCard = putIfAbsent(metadata.TwitterCard, key, content)
+	}
+}
+
+func putIfAbsent(values map[string]string, key, value string) map[string]string {
+	if values == nil {
+		values = map[string]string{}
+	}
+	if _, exists := values[key]; !exists {
+		values[key] = value
+	}
+	return values
+}
+
+// deriveMetadata populates the metadata of the page, resolving the canonical and
+// alternate urls against the base url of the document.
+func deriveMetadata(status *parsingState, info *AnalysisData) {
+	baseUrl := status.documentBaseUrl(info.SourceUrl)
+	info.Metadata = status.metadata
+	if canonical := status.metadata.Canonical; canonical != "" {
+		if resolved, err := getFinalUrl(canonical, baseUrl); err == nil {
+			info.Metadata.Canonical = resolved
+		}
+	}
+	if len(status.metadata.Alternates) > 0 {
+		info.Metadata.Alternates = make([]AlternateLink, 0, len(status.metadata.Alternates))
+		for _, alternate := range status.metadata.Alternates {
+			if resolved, err := getFinalUrl(alternate.Url, baseUrl); err == nil {
+				alternate.Url = resolved
+			}
+			info.Metadata.Alternates = append(info.Metadata.Alternates, alternate)
+		}
+	}
+}
+
+// auditSeo flags common SEO issues of the page, such as missing or duplicate titles and
+// descriptions, and canonical links pointing to broken or redirected urls. Canonical links
+// are verified, unless they are already verified as a link of the page.
+func (a *Analyzer) auditSeo(ctx context.Context, status *parsingState, info *AnalysisData) {
+	title := strings.Join(strings.Fields(info.Title), " ")
+	switch length := utf8.RuneCountInString(title); {
+	case status.titleCount == 0 || length == 0:
+		info.addSeoIssue(MissingTitle, "page has no title")
+	case length < minTitleLength:
+		info.addSeoIssue(TitleTooShort, fmt.Sprintf("title is %d characters long, shorter than %d", length, minTitleLength))
+	case length > maxTitleLength:
+		info.addSeoIssue(TitleTooLong, fmt.Sprintf("title is %d characters long, longer than %d", length, maxTitleLength))
+	}
+	if status.titleCount > 1 {
+		info.addSeoIssue(DuplicateTitle, fmt.Sprintf("page has %d titles", status.titleCount))
+	}
+
+	if info.Metadata.Description == "" {
+		info.addSeoIssue(MissingDescription, "page has no meta description")
+	}
+	if status.descriptionCount > 1 {
+		info.addSeoIssue(DuplicateDescription, fmt.Sprintf("page has %d meta descriptions", status.descriptionCount))
+	}
+
+	h1Count := 0
+	walkOutline(info.Outline, 0, func(heading *Heading, parentLevel int) {
+		if heading.Level == 1 {
+			h1Count++
+		}
+		if heading.SkippedLevels > 0 && parentLevel == 0 {
+			info.addSeoIssue(SkippedHeadingLevel, fmt.Sprintf("h%d appears before any h1", heading.Level))
+		} else if heading.SkippedLevels > 0 {
+			info.addSeoIssue(SkippedHeadingLevel, fmt.Sprintf("h%d follows h%d", heading.Level, parentLevel))
+		}
+	})
+	if h1Count > 1 {
+		info.addSeoIssue(MultipleH1, fmt.Sprintf("page has %d h1 headings", h1Count))
+	}
+
+	if canonical := info.Metadata.Canonical; canonical != "" {
+		link, ok := a.canonicalStatus(ctx, canonical, info)
+		if !ok {
+			return
+		}
+		if !link.IsValid {
+			info.addSeoIssue(BrokenCanonical, fmt.Sprintf("canonical url %s is broken (%d %s)", canonical, link.StatusCode, link.ErrorCategory))
+		} else if len(link.Redirects) > 0 {
+			info.addSeoIssue(RedirectedCanonical, fmt.Sprintf("canonical url %s redirects to %s", canonical, link.FinalUrl))
+		}
+	}
+}
+
+// canonicalStatus returns the status of the given canonical url. It returns false, if the url
+// cannot be verified since it is blocked by robots.txt or the analysis is cancelled.
+func (a *Analyzer) canonicalStatus(ctx context.Context, canonical string, info *AnalysisData) (LinkStatus, bool) {
+	for _, link := range info.LinkStats.Links {
+		if link.Verified && link.Url == canonical {
+			return link, true
+		}
+	}
+	if parsed, err := url.Parse(canonical); err != nil || !isHttpUrl(parsed) {
+		return LinkStatus{Url: canonical, StatusCode: 999, ErrorCategory: UnresolvableUrl}, true
+	}
+	if !a.allowedByRobots(ctx, canonical) {
+		return LinkStatus{}, false
+	}
+	link := a.checkLink(ctx, canonical, 0, nil)
+	return link, ctx.Err() == nil
+}
+
+func (info *AnalysisData) addSeoIssue(code, message string) {
+	info.SeoIssues = append(info.SeoIssues, SeoIssue{Code: code, Message: message})
+}
@@ -0,0 +1,201 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveMetadata(t *testing.T) {
+	// GIVEN
+	content := `<html><head>
+		<meta charset="UTF-8">
+		<base href="https://www.linklens.com/docs/">
+		<title>Link Lens</title>
+		<meta name="description" content=" Finds broken links ">
+		<meta name="Description" content="Second description">
+		<meta name="robots" content="noindex, nofollow">
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<meta property="og:title" content="Link Lens">
+		<meta property="og:image" content="https://www.linklens.com/og.png">
+		<meta property="og:image" content="https://www.linklens.com/og2.png">
+		<meta name="twitter:card" content="summary">
+		<link rel="canonical" href="intro">
+		<link rel="alternate" hreflang="si" href="/si/docs/intro">
+		<link rel="alternate" hreflang="x-default" href="https://www.linklens.com/docs/intro">
+		<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+	</head><body><svg><title>Logo</title></svg></body></html>`
+	info := NewAnalysis("https://www.linklens.com/page")
+	status, err := parseHtmlContent(strings.NewReader(content), info, builtins()...)
+	assert.NoError(t, err)
+
+	// WHEN
+	deriveMetadata(status, info)
+
+	// THEN
+	assert.Equal(t, Metadata{
+		Description: "Finds broken links",
+		Robots:      "noindex, nofollow",
+		Canonical:   "https://www.linklens.com/docs/intro",
+		Alternates: []AlternateLink{
+			{Hreflang: "si", Url: "https://www.linklens.com/si/docs/intro"},
+			{Hreflang: "x-default", Url: "https://www.linklens.com/docs/intro"},
+		},
+		OpenGraph:   map[string]string{"og:title": "Link Lens", "og:image": "https://www.linklens.com/og.png"},
+		TwitterCard: map[string]string{"twitter:card": "summary"},
+		Viewport:    "width=device-width, initial-scale=1",
+		Charset:     "UTF-8",
+	}, info.Metadata)
+	assert.Equal(t, "Link Lens", info.Title)
+	assert.Equal(t, 1, status.titleCount)
+	assert.Equal(t, 2, status.descriptionCount)
+}
+
+func TestDeriveMetadata_HttpEquivCharset(t *testing.T) {
+	// GIVEN
+	content := `<html><head><meta http-equiv="Content-Type" content="text/html; charset=ISO-8859-1"></head></html>`
+	info := NewAnalysis("https://www.linklens.com/page")
+	status, err := parseHtmlContent(strings.NewReader(content), info, builtins()...)
+	assert.NoError(t, err)
+
+	// WHEN
+	deriveMetadata(status, info)
+
+	// THEN
+	assert.Equal(t, "iso-8859-1", info.Metadata.Charset)
+}
+
+func TestAuditSeo(t *testing.T) {
+	description := `<meta name="description" content="Finds broken links">`
+	testcases := map[string]struct {
+		content string
+		issues  []SeoIssue
+	}{
+		"No Issues": {
+			content: `<title>Link Lens - Find broken links in your site</title>` + description + `<h1>A</h1><h2>B</h2><h3>C</h3><h2>D</h2>`,
+		},
+		"Missing Title And Description": {
+			content: `<h1>A</h1>`,
+			issues: []SeoIssue{
+				{Code: MissingTitle, Message: "page has no title"},
+				{Code: MissingDescription, Message: "page has no meta description"},
+			},
+		},
+		"Empty Title": {
+			content: `<title>  </title>` + description,
+			issues:  []SeoIssue{{Code: MissingTitle, Message: "page has no title"}},
+		},
+		"Short And Duplicate Title": {
+			content: `<title>Home</title><title>Home again</title>` + description + description,
+			issues: []SeoIssue{
+				{Code: TitleTooShort, Message: "title is 4 characters long, shorter than 30"},
+				{Code: DuplicateTitle, Message: "page has 2 titles"},
+				{Code: DuplicateDescription, Message: "page has 2 meta descriptions"},
+			},
+		},
+		"Svg Title": {
+			content: `<title>Link Lens - Find broken links in your site</title>` + description + `<svg><title>x</title></svg>`,
+		},
+		"Long Title": {
+			content: `<title>` + strings.Repeat("ශ", 61) + `</title>` + description,
+			issues:  []SeoIssue{{Code: TitleTooLong, Message: "title is 61 characters long, longer than 60"}},
+		},
+		"Heading Issues": {
+			content: `<title>Link Lens - Find broken links in your site</title>` + description +
+				`<h1>A</h1><h3>B</h3><h1>C</h1><h2>D</h2><h6>E</h6>`,
+			issues: []SeoIssue{
+				{Code: SkippedHeadingLevel, Message: "h3 follows h1"},
+				{Code: SkippedHeadingLevel, Message: "h6 follows h2"},
+				{Code: MultipleH1, Message: "page has 2 h1 headings"},
+			},
+		},
+		"Heading Before H1": {
+			content: `<title>Link Lens - Find broken links in your site</title>` + description + `<h2>A</h2><h1>B</h1><h2>C</h2>`,
+			issues:  []SeoIssue{{Code: SkippedHeadingLevel, Message: "h2 appears before any h1"}},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			info := NewAnalysis("https://www.linklens.com/page")
+			status, err := parseHtmlContent(strings.NewReader(test.content), info, builtins()...)
+			assert.NoError(t, err)
+			deriveMetadata(status, info)
+			deriveOutline(status, info)
+
+			// WHEN
+			defaultAnalyzer.auditSeo(context.Background(), status, info)
+
+			// THEN
+			assert.Equal(t, test.issues, info.SeoIssues)
+		})
+	}
+}
+
+func TestAnalyzeUrl_Canonical(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := func(canonical string) {
+			w.Header().Set("content-type", "text/html")
+			fmt.Fprintf(w, `<html><head><title>Link Lens - Find broken links in your site</title>
+				<meta name="description" content="Finds broken links"><link rel="canonical" href="%s"></head>
+				<body><a href="/linked">Linked</a></body></html>`, canonical)
+		}
+		switch r.URL.Path {
+		case "/valid":
+			page("/valid")
+		case "/broken":
+			page("/missing")
+		case "/redirected":
+			page("/old")
+		case "/linked-canonical":
+			page("/linked")
+		case "/unsupported":
+			page("ftp://www.linklens.com/file")
+		case "/old":
+			http.Redirect(w, r, "/valid", http.StatusMovedPermanently)
+		case "/linked":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	testcases := map[string]struct {
+		path   string
+		issues []SeoIssue
+	}{
+		"Valid Canonical":              {path: "/valid"},
+		"Canonical Verified As A Link": {path: "/linked-canonical"},
+		"Broken Canonical": {
+			path:   "/broken",
+			issues: []SeoIssue{{Code: BrokenCanonical, Message: fmt.Sprintf("canonical url %s/missing is broken (404 HttpStatusError)", server.URL)}},
+		},
+		"Redirected Canonical": {
+			path:   "/redirected",
+			issues: []SeoIssue{{Code: RedirectedCanonical, Message: fmt.Sprintf("canonical url %s/old redirects to %s/valid", server.URL, server.URL)}},
+		},
+		"Unsupported Canonical": {
+			path:   "/unsupported",
+			issues: []SeoIssue{{Code: BrokenCanonical, Message: "canonical url ftp://www.linklens.com/file is broken (999 UnresolvableUrl)"}},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			info, err := NewAnalyzer(Options{}).AnalyzeUrl(context.Background(), server.URL+test.path, &OneDepthCrawler{})
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, test.issues, info.SeoIssues)
+		})
+	}
+}
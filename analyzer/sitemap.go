@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Maximum size of a sitemap as defined in the sitemap protocol.
+const maxSitemapSize = 50 * 1024 * 1024
+
+// Either a urlset or a sitemap index as defined in https://www.sitemaps.org/protocol.html
+type sitemapDocument struct {
+	XMLName  xml.Name
+	Urls     []sitemapLocation `xml:"url"`
+	Sitemaps []sitemapLocation `xml:"sitemap"`
+}
+
+type sitemapLocation struct {
+	Loc string `xml:"loc"`
+}
+
+// FetchSitemap fetches the sitemap in the given url and returns the urls of all pages listed
+// in it without duplicates. If the sitemap is a sitemap index, then the pages of all
+// nested sitemaps are returned. Nested sitemaps which cannot be fetched are skipped.
+// Only the first urls up to the given limit are returned, and the rest of the nested
+// sitemaps are not fetched once the limit is reached, in which case the returned flag
+// is set. Zero or negative limit means no limit.
+func (a *Analyzer) FetchSitemap(ctx context.Context, sitemapUrl string, limit int) ([]string, bool, error) {
+	doc, err := a.fetchSitemapDocument(ctx, sitemapUrl)
+	if err != nil {
+		return nil, false, err
+	}
+
+	urls := []string{}
+	seen := map[string]bool{}
+	collect := func(pages []sitemapLocation) {
+		for _, page := range pages {
+			pageUrl := strings.TrimSpace(page.Loc)
+			if pageUrl != "" && !seen[pageUrl] {
+				seen[pageUrl] = true
+				urls = append(urls, pageUrl)
+			}
+		}
+	}
+
+	collect(doc.Urls)
+	for i, nested := range doc.Sitemaps {
+		if limit > 0 && len(urls) >= limit {
+			slog.Warn("Sitemap url limit reached! Rest of nested sitemaps will not be fetched.", "sitemap", sitemapUrl, "skipped#", len(doc.Sitemaps)-i)
+			return urls[:limit], true, nil
+		}
+		// indexes are followed only a single level, as they cannot be nested by the protocol.
+		nestedDoc, err := a.fetchSitemapDocument(ctx, strings.TrimSpace(nested.Loc))
+		if err != nil {
+			slog.Warn("Skipping the nested sitemap", "url", nested.Loc, "reason", err)
+			continue
+		}
+		collect(nestedDoc.Urls)
+	}
+
+	if limit > 0 && len(urls) > limit {
+		return urls[:limit], true, nil
+	}
+	return urls, false, nil
+}
+
+func (a *Analyzer) fetchSitemapDocument(ctx context.Context, sitemapUrl string) (*sitemapDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapUrl, nil)
+	if err != nil {
+		return nil, &AnalysisError{
+			ErrorCode: ErrorInvalidUrl,
+			Cause:     fmt.Errorf("given sitemap url is malformed"),
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, &AnalysisError{
+			ErrorCode: RemoteFetchError,
+			Cause:     fmt.Errorf("cannot fetch the sitemap from url"),
+		}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, &AnalysisError{
+			ErrorCode: UnsuccessfulStatusCode,
+			Cause:     fmt.Errorf("unsuccessful status code returned for the sitemap url! %d", resp.StatusCode),
+		}
+	}
+
+	var doc sitemapDocument
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxSitemapSize)).Decode(&doc); err != nil {
+		return nil, &AnalysisError{
+			ErrorCode: InvalidSitemap,
+			Cause:     fmt.Errorf("sitemap is not a valid xml! %w", err),
+		}
+	} else if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, &AnalysisError{
+			ErrorCode: InvalidSitemap,
+			Cause:     fmt.Errorf("sitemap must be either a urlset or a sitemapindex! %s", doc.XMLName.Local),
+		}
+	}
+	return &doc, nil
+}
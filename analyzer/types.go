@@ -1,52 +1,646 @@
 package analyzer
 
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Types of pages
+const (
+	LoginForm         = "LoginForm"
+	SignUpForm        = "SignUpForm"
+	PasswordResetForm = "PasswordResetForm"
+	SearchPage        = "SearchPage"
+	CheckoutForm      = "CheckoutForm"
+	ContactForm       = "ContactForm"
+	ArticlePage       = "ArticlePage"
+	SoftErrorPage     = "SoftErrorPage"
+	Unknown           = "Unknown"
+)
+
+// Kinds of links
+const (
+	InternalLink    = "Internal"
+	ExternalLink    = "External"
+	AnchorLink      = "Anchor"
+	MailtoLink      = "Mailto"
+	TelLink         = "Tel"
+	JavascriptLink  = "Javascript"
+	OtherSchemeLink = "OtherScheme"
+)
+
+// Types of resources
+const (
+	ImageResource      = "Image"
+	ScriptResource     = "Script"
+	StylesheetResource = "Stylesheet"
+	IconResource       = "Icon"
+	PreloadResource    = "Preload"
+	VideoResource      = "Video"
+	AudioResource      = "Audio"
+	FrameResource      = "Frame"
+	ObjectResource     = "Object"
+)
+
+// Variants of html 4 and xhtml 1.0 doctypes
+const (
+	StrictVariant       = "Strict"
+	TransitionalVariant = "Transitional"
+	FramesetVariant     = "Frameset"
+)
+
+// Modes which browsers render pages in, as triggered by the doctype
+const (
+	QuirksMode        = "Quirks"
+	LimitedQuirksMode = "LimitedQuirks"
+	StandardsMode     = "Standards"
+)
+
+// Names of the built-in inspectors, in the order they run
+const (
+	DoctypeInspector       = "doctype"
+	TitleInspector         = "title"
+	HeadingsInspector      = "headings"
+	LinksInspector         = "links"
+	ResourcesInspector     = "resources"
+	FormsInspector         = "forms"
+	PageTypeInspector      = "pageType"
+	SeoInspector           = "seo"
+	AccessibilityInspector = "accessibility"
+)
+
+// Codes of SEO issues
+const (
+	MissingTitle         = "MissingTitle"
+	DuplicateTitle       = "DuplicateTitle"
+	TitleTooShort        = "TitleTooShort"
+	TitleTooLong         = "TitleTooLong"
+	MissingDescription   = "MissingDescription"
+	DuplicateDescription = "DuplicateDescription"
+	MultipleH1           = "MultipleH1"
+	SkippedHeadingLevel  = "SkippedHeadingLevel"
+	BrokenCanonical      = "BrokenCanonical"
+	RedirectedCanonical  = "RedirectedCanonical"
+)
+
+// Codes of accessibility issues
 const (
-	LoginForm = "LoginForm"
-	Unknown   = "Unknown"
+	MissingAltText    = "MissingAltText"
+	MissingLabel      = "MissingLabel"
+	EmptyLinkText     = "EmptyLinkText"
+	GenericLinkText   = "GenericLinkText"
+	MissingLang       = "MissingLang"
+	DuplicateId       = "DuplicateId"
+	MissingButtonName = "MissingButtonName"
+	MissingLandmark   = "MissingLandmark"
 )
 
+const (
+	// Default number of pages visited by the DepthCrawler, when no limit is specified.
+	DefaultMaxPages = 100
+	// Default number of links verified at the same time.
+	DefaultConcurrency = 20
+	// Default number of links verified at the same time in a single host.
+	DefaultPerHostConcurrency = 4
+	// Default number of pages analyzed at the same time in a batch.
+	DefaultBatchConcurrency = 4
+	// Default number of redirects followed before giving up.
+	DefaultMaxRedirects = 10
+	// Default delay before the first retry of a failed link check.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// Default maximum delay between retries, including delays asked by Retry-After headers.
+	DefaultMaxRetryBackoff = 30 * time.Second
+	// Default number of entries kept in the memory cache.
+	DefaultCacheCapacity = 1000
+	// Default duration to keep cached entries.
+	DefaultCacheTTL = 10 * time.Minute
+	// Default user agent sent with all requests.
+	DefaultUserAgent = "LinkLens/1.0 (+https://github.com/isuru89/link-lens)"
+)
+
+// Status of a single link found in a page.
 type LinkStatus struct {
-	Url        string
+	// Href as found in the page.
+	Href string
+	// Absolute url resolved from the href. Empty if the href cannot be resolved.
+	Url string
+	// One of the kinds of links. (e.g. InternalLink, ExternalLink, MailtoLink)
+	Kind string
+	// Text of the anchor element. For image links, alt text of the image.
+	Text string
+	// Whether the link was verified. Anchor links, non-http links and links
+	// skipped due to crawl limits, cancellation or robots.txt are not verified.
+	Verified   bool
 	IsValid    bool
 	StatusCode int
+	// Reason for an invalid link. One of the error categories. (e.g. DnsError, TimeoutError)
+	ErrorCategory  string `json:",omitempty"`
+	ResponseTimeMs int64
+	// Each redirect followed in order, before reaching the final url.
+	Redirects []RedirectHop `json:",omitempty"`
+	// Url which returned the final status code, after following all redirects.
+	FinalUrl string `json:",omitempty"`
+	// Whether redirects form a loop. Such links are always invalid.
+	RedirectLoop bool `json:",omitempty"`
+	// Whether the robots.txt of the site disallows verifying the link.
+	// Such links are neither valid nor invalid.
+	BlockedByRobots bool `json:",omitempty"`
+}
+
+// A single redirect response received while verifying a link.
+type RedirectHop struct {
+	Url        string
+	StatusCode int
 }
 
 type LinkStats struct {
+	// Http links pointing to the same site (including anchor links), or to other sites.
 	InternalLinkCount int
 	ExternalLinkCount int
-	InvalidLinkCount  int
-	InvalidLinks      []string
+	// Links using non-http schemes, which are not verified.
+	MailtoLinkCount      int
+	TelLinkCount         int
+	JavascriptLinkCount  int
+	OtherSchemeLinkCount int
+	InvalidLinkCount     int
+	InvalidLinks         []string
+	// Number of links redirected at least once, and how many of them
+	// were permanently (301, 308) or temporarily (302, 303, 307) redirected.
+	RedirectedLinkCount    int
+	PermanentRedirectCount int
+	TemporaryRedirectCount int
+	// Status of all redirected links including their redirect chains.
+	RedirectedLinks []LinkStatus `json:",omitempty"`
+	// Links not verified, because robots.txt of their sites disallows.
+	BlockedLinkCount int
+	BlockedLinks     []string `json:",omitempty"`
+	// Status of all links found in the crawled page(s).
+	Links []LinkStatus `json:",omitempty"`
+	Pages []PageReport `json:",omitempty"`
+	// Whether crawling was cancelled before verifying all links.
+	Incomplete bool
+}
+
+// Status of a resource (e.g. image, script) loaded by the page.
+type ResourceStatus struct {
+	// One of the types of resources. (e.g. ImageResource, ScriptResource)
+	Type string
+	LinkStatus
+}
+
+// Statistics of resources loaded by the page, broken down by the resource types.
+type ResourceStats struct {
+	ResourceCount       int
+	BrokenResourceCount int
+	// Statistics of each type of resources found in the page.
+	Types map[string]ResourceTypeStats `json:",omitempty"`
+	// Status of all resources found in the page.
+	Resources []ResourceStatus `json:",omitempty"`
+	// Whether crawling was cancelled before verifying all resources.
+	Incomplete bool
+}
+
+type ResourceTypeStats struct {
+	Count           int
+	BrokenCount     int
+	BrokenResources []string `json:",omitempty"`
+}
+
+// Broken link report of a single page visited during a multi-depth crawl.
+type PageReport struct {
+	Url       string
+	Depth     int
+	LinkStats LinkStats
 }
 
 // Base interface for all possible crawling strategies.
+// Crawlers must use the given analyzer to fetch pages and verify links.
+// Links are the hrefs found in the page mapped to their anchor text, which
+// resolve against the base url of the page. (i.e. the <base href> if given)
+// Crawlers must stop crawling when the context is cancelled, and
+// return the partial statistics marked as incomplete.
 type Crawler interface {
-	Crawl(baseUrl string, links map[string]bool) *LinkStats
+	Crawl(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, links map[string]string) *LinkStats
+}
+
+// Crawlers which also verify resources loaded by the page (e.g. images, scripts),
+// which are given mapped to their types. Resources are not verified, if the crawler
+// does not implement this interface.
+type ResourceCrawler interface {
+	CrawlResources(ctx context.Context, a *Analyzer, pageUrl, baseUrl string, resources map[string]string) *ResourceStats
+}
+
+// Options to control how the analyzer sends http requests.
+// Zero values of all fields fallback to the defaults.
+type Options struct {
+	// Maximum time to wait until a connection is established.
+	ConnectTimeout time.Duration
+	// Maximum time to wait for response headers after sending a request.
+	ReadTimeout time.Duration
+	// Maximum number of redirects to follow. Zero means DefaultMaxRedirects, while a negative
+	// value treats every redirect as an error. (i.e. TooManyRedirectsError for links)
+	MaxRedirects int
+	// User agent sent with all requests. Defaults to DefaultUserAgent.
+	UserAgent string
+	// Extra headers sent with all requests.
+	Headers map[string]string
+	// Transport used to send requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// Cache of analysis results and link statuses. Nil disables caching.
+	Cache Cache
+	// Ignores robots.txt of all sites, including the crawl delays.
+	IgnoreRobots bool
+	// Hosts whose robots.txt is ignored. (e.g. sites we own)
+	RobotsExemptHosts []string
+	// Policy to retry link checks failed due to transient errors.
+	Retry RetryPolicy
+	// Maximum number of requests sent to a single host per second. Zero means no limit.
+	HostRateLimit float64
+	// Custom inspectors mapped by their names, which run after the built-in inspectors
+	// in the order of their names. Names of built-in inspectors cannot be used.
+	Inspectors map[string]InspectorFactory
+}
+
+// Policy to retry link checks failed due to transient errors (e.g. 503 responses or
+// connection resets). Delays between attempts grow exponentially with a random jitter,
+// unless the response asks to retry after a specific delay using a Retry-After header.
+type RetryPolicy struct {
+	// Maximum number of attempts including the first one. Zero means a single attempt.
+	MaxAttempts int
+	// Delay before the first retry, which is doubled after each attempt.
+	// Defaults to DefaultRetryBackoff.
+	Backoff time.Duration
+	// Maximum delay between attempts. Links asking to retry after a longer delay
+	// are not retried. Defaults to DefaultMaxRetryBackoff.
+	MaxBackoff time.Duration
+	// Status codes which are retried. Defaults to 429, 502, 503 and 504.
+	StatusCodes []int
+	// Error categories which are retried. Defaults to TimeoutError and ConnectionError.
+	ErrorCategories []string
+}
+
+// Analyzer fetches and analyzes pages, and verifies links using its own http client.
+// It is safe to use an analyzer concurrently.
+type Analyzer struct {
+	client *http.Client
+	// client used for verifying links, which does not follow redirects automatically.
+	linkClient *http.Client
+	options    Options
+	// link checks shared among all pages of a batch, if analyzing a batch.
+	checks *linkChecks
+	// listener notified about the progress, if any.
+	listener Listener
+	// skips reading cached results, while still caching new results.
+	bypassCache bool
+	// robots.txt rules of all sites. Nil if robots.txt is ignored.
+	robots *robotsCache
+	// rate of requests sent to each host.
+	rates *rateLimiter
+	// names of the inspectors selected to run. All inspectors run, if empty.
+	inspectors []string
+}
+
+// Inspector examines the tokens of a single page, and contributes a section to the analysis.
+// A new inspector is created for each analyzed page, hence it can keep the state of the page.
+// Built-in inspectors are the exception, as they fill the top-level fields of the analysis
+// (e.g. Title, LinkStats) instead of reporting sections.
+type Inspector interface {
+	// Inspect is called for each doctype, tag and text token of the page in the order of
+	// appearance. The token is shared by all inspectors, hence it must not be modified.
+	Inspect(token *html.Token, page *Page)
+	// Report is called after parsing the page and verifying its links and resources, and
+	// returns the section added to the analysis under the name of the inspector, if any.
+	Report(ctx context.Context, page *Page) any
+}
+
+// InspectorFactory creates an inspector for a single page.
+type InspectorFactory func() Inspector
+
+// Page being inspected, which is shared by all inspectors of the page.
+type Page struct {
+	// Analysis of the page being built. When reporting, it has the sections reported by the
+	// inspectors run before, along with the statistics of links and resources.
+	Analysis *AnalysisData
+	status   *parsingState
+	// analyzer used to verify urls while reporting. Nil while parsing.
+	analyzer *Analyzer
+}
+
+// Types of progress events
+const (
+	PageParsedEvent  = "PageParsed"
+	LinkCheckedEvent = "LinkChecked"
+)
+
+// Progress of an analysis reported to a listener.
+type ProgressEvent struct {
+	Type string
+	// Base url of the page which links are found in.
+	PageUrl string
+	// Number of links found in the page, and the number of links out of them to be verified.
+	// Only set in PageParsed events.
+	LinkCount, PendingLinkCount int
+	// Status of the verified link. Only set in LinkChecked events.
+	Link *LinkStatus
+}
+
+// Listener receives progress events of an analysis.
+// It may be called concurrently, when many pages are analyzed at once.
+type Listener func(event ProgressEvent)
+
+// Common configurations shared by all crawling strategies when verifying links.
+// Zero values keep the default behaviour, i.e. all links are verified without a timeout.
+type CrawlConfig struct {
+	// Maximum number of links to verify in a single page. Zero means no limit.
+	MaxLinks int
+	// Skips verifying external links.
+	SkipExternal bool
+	// Treats links to subdomains of the site as internal links. (e.g. docs.a.com in www.a.com)
+	InternalSubdomains bool
+	// Other domains treated as part of the same site, hence links to them are internal.
+	SiblingDomains []string
+	// Timeout for each request sent to verify a single link, excluding the time waiting for
+	// the crawl delay, the rate limit of its site and the backoff between retries.
+	// Zero means no timeout.
+	LinkTimeout time.Duration
+	// Maximum number of links verified at the same time. Zero means DefaultConcurrency.
+	Concurrency int
+	// Maximum number of links verified at the same time in a single host.
+	// Zero means DefaultPerHostConcurrency.
+	PerHostConcurrency int
 }
 
 // Crawl only to a single level depth.
 type OneDepthCrawler struct {
+	CrawlConfig
+}
+
+// Crawl recursively through internal links up to the given depth.
+// MaxDepth of 0 behaves same as the OneDepthCrawler, and MaxPages limits
+// the total number of pages to visit including the source page.
+type DepthCrawler struct {
+	CrawlConfig
+	MaxDepth int
+	MaxPages int
+}
+
+// Result of analyzing a single url in a batch.
+// Either the analysis data or the error is set.
+type BatchResult struct {
+	Url   string
+	Data  *AnalysisData
+	Error error
 }
 
 type AnalysisData struct {
-	SourceUrl     string
+	SourceUrl string
+	// Html version declared by the doctype. (e.g. 5, 4.01, XHTML 1.0)
+	// Unknown when the page has no doctype, or the doctype is not recognized.
 	HtmlVersion   string
+	Doctype       Doctype
 	Title         string
 	HeadingsCount map[string]int
-	LinkStats     LinkStats
-	PageType      string
+	// Headings of the page in the order of appearance, nested by their levels.
+	Outline   []Heading `json:",omitempty"`
+	LinkStats LinkStats
+	// Resources loaded by the analyzed page. Resources of other pages visited
+	// by the DepthCrawler are not included.
+	ResourceStats ResourceStats
+	// One of the types of pages. (e.g. LoginForm, ArticlePage)
+	PageType string
+	// Confidence of the page type between 0 and 1, and the signals found in the page
+	// which support the page type. Not set for unknown pages.
+	PageTypeConfidence float64  `json:",omitempty"`
+	PageTypeSignals    []string `json:",omitempty"`
+	// All forms found in the page.
+	Forms []FormInfo `json:",omitempty"`
+	// SEO related metadata of the page, and the issues found by auditing it.
+	Metadata  Metadata
+	SeoIssues []SeoIssue `json:",omitempty"`
+	// Accessibility issues found in the page. Issues of the whole page are reported first.
+	AccessibilityIssues []AccessibilityIssue `json:",omitempty"`
+	// Sections reported by custom inspectors mapped by their names.
+	Sections map[string]any `json:",omitempty"`
+	// Only set when the analysis is served from the cache.
+	Cache *CacheStatus `json:",omitempty"`
+}
+
+// A form found in the page along with its fields and security issues.
+type FormInfo struct {
+	// Absolute url which the form is submitted to. Same as the page url when not given.
+	Action string
+	// Http method in upper case. Defaults to GET.
+	Method  string
+	Id      string `json:",omitempty"`
+	Name    string `json:",omitempty"`
+	Fields  []FormField
+	Buttons []FormButton
+	// Whether the form has a password field, and is submitted over plain http.
+	InsecurePassword bool
+	// Whether the form is submitted using POST, but has no hidden field looking like a CSRF token.
+	MissingCsrfToken bool
+	// Whether the form is submitted to another origin than the page.
+	CrossOriginAction bool
+}
+
+// An input, select or textarea element of a form.
+type FormField struct {
+	// One of input, select or textarea.
+	Element string
+	// Type of an input element in lower case. (e.g. text, password, hidden)
+	Type     string `json:",omitempty"`
+	Name     string
+	Required bool
+}
+
+// A button or a submit input of a form.
+type FormButton struct {
+	// One of submit, reset, button or image.
+	Type string
+	Text string
+}
+
+// Details of an analysis served from the cache.
+type CacheStatus struct {
+	Hit      bool
+	StoredAt time.Time
+	AgeMs    int64
+}
+
+// Doctype of the page, and the rendering mode it triggers in browsers.
+type Doctype struct {
+	// Whether the page has a doctype. Pages without a doctype are rendered in the quirks mode.
+	Present bool
+	// Same as the html version of the analysis.
+	Version string
+	// One of the variants of html 4 and xhtml 1.0 doctypes. (i.e. Strict, Transitional, Frameset)
+	Variant string `json:",omitempty"`
+	// One of the rendering modes. (i.e. Quirks, LimitedQuirks, Standards)
+	Mode string
+}
+
+// A heading (i.e. h1 to h6) of the document outline, along with its sub headings.
+type Heading struct {
+	Level int
+	Text  string
+	// Number of levels skipped from the parent heading. (e.g. 1 for a h3 under a h1)
+	// Top level headings other than h1 are considered as skipping levels too.
+	SkippedLevels int       `json:",omitempty"`
+	Children      []Heading `json:",omitempty"`
+}
+
+// SEO related metadata found in the head of the page.
+type Metadata struct {
+	Description string `json:",omitempty"`
+	// Content of the robots meta. (e.g. noindex, nofollow)
+	Robots string `json:",omitempty"`
+	// Url of the canonical link, resolved against the base url of the document.
+	Canonical string `json:",omitempty"`
+	// Alternate urls of the page in other languages, given by hreflang links.
+	Alternates []AlternateLink `json:",omitempty"`
+	// Open Graph (og:*) and Twitter card (twitter:*) tags mapped by their names.
+	OpenGraph   map[string]string `json:",omitempty"`
+	TwitterCard map[string]string `json:",omitempty"`
+	Viewport    string            `json:",omitempty"`
+	Charset     string            `json:",omitempty"`
+}
+
+type AlternateLink struct {
+	Hreflang string
+	Url      string
+}
+
+// An SEO issue found in the page.
+type SeoIssue struct {
+	// One of the codes of SEO issues. (e.g. MissingTitle, BrokenCanonical)
+	Code    string
+	Message string
+}
+
+// An accessibility issue found in the page.
+type AccessibilityIssue struct {
+	// One of the codes of accessibility issues. (e.g. MissingAltText, MissingLabel)
+	Code string
+	// The element having the issue as found in the page, shortened if too long.
+	// Not set for issues of the whole page. (e.g. MissingLang)
+	Element string `json:",omitempty"`
+	Message string
 }
 
 // Stores internal analysis and parsing status.
 type parsingState struct {
-	allLinks        map[string]bool
-	currTag         string
+	// all links found in the page mapped to their anchor text.
+	allLinks map[string]string
+	// all resources found in the page mapped to their types, and the media element
+	// (i.e. picture, video or audio) which the source elements being parsed belong to.
+	resources    map[string]string
+	mediaElement string
+	currLink     string
+	linkText     strings.Builder
+	// whether the doctype is found, as only the first doctype is considered.
+	hasDoctype bool
+	// href of the first base element
+	baseHref        string
+	hasBase         bool
 	inputTypeCounts map[string]int
+	// forms found in the page, the form being parsed, and the inputs outside of forms.
+	forms       []*formState
+	currForm    *formState
+	looseInputs *formState
+	// content of meta elements by their lower cased name or property.
+	metas map[string]string
+	// text of the first h1 element, and whether it is being parsed.
+	h1Text     strings.Builder
+	inH1       bool
+	h1Captured bool
+	// button being parsed and its text, if any.
+	currButton *FormButton
+	buttonText strings.Builder
+	// whether the page has article elements and search landmarks.
+	hasArticle bool
+	hasSearch  bool
+	// number of words in paragraphs, and the depth of the paragraph being parsed.
+	paragraphDepth int
+	paragraphWords int
+	// metadata as found in the page, without resolving urls.
+	metadata Metadata
+	// number of title elements (excluding svg titles) and description metas, and the
+	// depth of the svg element being parsed.
+	titleCount       int
+	descriptionCount int
+	svgDepth         int
+	// all headings in the order of appearance without nesting, and the text of the
+	// heading being parsed, if any.
+	headings    []Heading
+	inHeading   bool
+	headingText strings.Builder
+	// elements collected for the accessibility audit.
+	a11y accessibilityState
+}
+
+// Elements and issues collected for the accessibility audit while parsing.
+type accessibilityState struct {
+	// issues found while parsing, in the order of appearance.
+	issues []AccessibilityIssue
+	// whether the html element has a lang attribute.
+	hasLang bool
+	// ids of elements found so far.
+	ids map[string]bool
+	// form fields, which are checked for labels at the end, as labels may follow fields.
+	fields []*accessibleElement
+	// ids referred by the for attribute of labels, and the depth of the label being parsed.
+	labelFors  map[string]bool
+	labelDepth int
+	// link and button being parsed, if any.
+	currLink   *accessibleElement
+	currButton *accessibleElement
+	// landmark roles found in the page (e.g. main, navigation), and the depth of the sectioning
+	// elements being parsed, as headers and footers inside them are not landmarks.
+	landmarks       map[string]bool
+	sectioningDepth int
+}
+
+// An element checked for its accessible name.
+type accessibleElement struct {
+	context string
+	id      string
+	// whether the element is named by aria attributes, a title or a parent label.
+	named bool
+	text  strings.Builder
+}
+
+// Structure of a form, used to build the form inventory and to identify the purpose of the page.
+type formState struct {
+	// action, id and name attributes as found in the page.
+	action, id, name string
+	// lower cased action, id, name and class attributes of the form.
+	identifiers string
+	method      string
+	inputs      []inputState
+	buttons     []FormButton
+}
+
+// An input, select or textarea element of a form.
+type inputState struct {
+	element string
+	// lower cased type of an input element.
+	inputType string
+	name      string
+	// name, id and placeholder joined together.
+	names        string
+	autocomplete string
+	required     bool
 }
 
 func NewAnalysis(url string) *AnalysisData {
 	return &AnalysisData{
 		SourceUrl:     url,
-		HtmlVersion:   "5",
+		HtmlVersion:   Unknown,
+		Doctype:       Doctype{Version: Unknown, Mode: QuirksMode},
 		HeadingsCount: map[string]int{},
 		LinkStats:     LinkStats{},
 	}
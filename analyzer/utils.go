@@ -2,107 +2,115 @@ package analyzer
 
 import (
 	"fmt"
-	"net/http"
-	"regexp"
+	"net/url"
 	"strings"
 )
 
-var baseUrlRegex = regexp.MustCompile(`(?i)(https?://[^/]+)/?`)
+// isAnchorLink returns true if the given href is a anchor link.
+// Anchor links usually starts with a hash.
+func isAnchorLink(href string) bool {
+	return strings.HasPrefix(href, "#")
+}
 
-// concatUrl returns a valid concatenated url by combining both
-// baseUrl and path parameters mixing forward slashes (/) correctly.
-func concatUrl(baseUrl, path string) string {
-	if path == "" {
-		return baseUrl
+// stripFragment removes the fragment part (#...) of the given url, if exists.
+func stripFragment(checkUrl string) string {
+	if pos := strings.Index(checkUrl, "#"); pos >= 0 {
+		return checkUrl[:pos]
 	}
+	return checkUrl
+}
 
-	startsWithHash := strings.HasPrefix(path, "/")
-	endsWithHash := strings.HasSuffix(baseUrl, "/")
+// linkKindOf returns the kind of the given href found in the given page having the base url.
+// Http links are internal, when they resolve to the same host as the page, or to one of
+// the sibling domains. Subdomains are treated as internal only if asked.
+func linkKindOf(href, pageUrl, baseUrl string, internalSubdomains bool, siblingDomains []string) string {
+	href = strings.TrimSpace(href)
+	if isAnchorLink(href) {
+		return AnchorLink
+	}
 
-	if endsWithHash && startsWithHash {
-		return baseUrl + strings.TrimPrefix(path, "/")
-	} else if !endsWithHash && !startsWithHash {
-		return baseUrl + "/" + path
-	} else {
-		return baseUrl + path
+	base, err := url.Parse(baseUrl)
+	if err != nil {
+		return InternalLink
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		// malformed hrefs are still authored as part of the site.
+		return InternalLink
 	}
-}
 
-// isAbsoluteUrl returns true if given href indicates a full absolute url.
-func isAbsoluteUrl(href string) bool {
-	return strings.Contains(href, "://")
+	link := base.ResolveReference(ref)
+	switch strings.ToLower(link.Scheme) {
+	case "http", "https":
+		pageHost := base.Hostname()
+		if page, err := url.Parse(pageUrl); err == nil {
+			pageHost = page.Hostname()
+		}
+		if isInternalHost(link.Hostname(), pageHost, internalSubdomains, siblingDomains) {
+			return InternalLink
+		}
+		return ExternalLink
+	case "mailto":
+		return MailtoLink
+	case "tel":
+		return TelLink
+	case "javascript":
+		return JavascriptLink
+	default:
+		return OtherSchemeLink
+	}
 }
 
-// isRelativeUrl returns true if the given href is a relative url
-// with respective to the base url. If the url starts with a forward
-// slash, then it indicates a relative path to the base url.
-// There is another variation of relative url which does not handle by this method.
-func isRelativeUrl(href string) bool {
-	return strings.HasPrefix(href, "/")
-}
+// isInternalHost returns true if the given host belongs to the same site as the page host.
+func isInternalHost(host, pageHost string, internalSubdomains bool, siblingDomains []string) bool {
+	host = strings.ToLower(host)
+	domains := []string{strings.ToLower(pageHost)}
+	for _, sibling := range siblingDomains {
+		domains = append(domains, strings.ToLower(sibling))
+	}
 
-// isAnchorLink returns true if the given href is a anchor link.
-// Anchor links usually starts with a hash.
-func isAnchorLink(href string) bool {
-	return strings.HasPrefix(href, "#")
+	for _, domain := range domains {
+		if host == domain {
+			return true
+		} else if internalSubdomains {
+			// www is only a conventional subdomain, hence www.a.com treats docs.a.com as its own subdomain.
+			domain = strings.TrimPrefix(domain, "www.")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // getFinalUrl returns the final absolute url we need to fetch or check.
-// This modifies the href as necessary with the source url analyzing.
+// The href is resolved against the source url as specified in RFC 3986,
+// hence the source url must be the base url of the document.
+// Only http and https urls are supported.
 func getFinalUrl(href, sourceUrl string) (string, error) {
 	if sourceUrl == "" {
 		return "", fmt.Errorf("source url cannot be empty")
 	}
 
-	baseUrlParts := baseUrlRegex.FindStringSubmatch(sourceUrl)
-	if baseUrlParts == nil {
+	baseUrl, err := url.Parse(sourceUrl)
+	if err != nil || !isHttpUrl(baseUrl) {
 		return "", fmt.Errorf("unable to find valid base url! either its unsupported portocol or malformed url")
 	}
 
-	if href == "" {
-		return sourceUrl, nil
-	}
-
-	if isAbsoluteUrl(href) {
-		// check for valid protocol
-		hrefParts := baseUrlRegex.FindStringSubmatch(href)
-		if hrefParts == nil {
-			return "", fmt.Errorf("unsupported href! either its unsupported portocol or malformed url")
-		}
-		return href, nil
-	} else if isAnchorLink(href) {
-		return sourceUrl + href, nil
-	}
-
-	baseUrl := baseUrlParts[1]
-
-	if isRelativeUrl(href) {
-		return concatUrl(baseUrl, href), nil
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", fmt.Errorf("malformed href! %w", err)
 	}
 
-	pos := strings.LastIndex(sourceUrl, "/")
-	dpos := strings.LastIndex(sourceUrl, "//")
-	if pos > 0 && dpos < pos-1 {
-		return sourceUrl[:pos+1] + href, nil
+	finalUrl := baseUrl.ResolveReference(ref)
+	if !isHttpUrl(finalUrl) {
+		return "", fmt.Errorf("unsupported href! either its unsupported portocol or malformed url")
 	}
-	return concatUrl(sourceUrl, href), nil
+	return finalUrl.String(), nil
 }
 
-// FindUrlValidity returns true if this given link is a valid one or not
-// by checking whether it returns a 2xx response.
-// Note: This method does not strictly check the content-type.
-func findUrlValidity(checkUrl string) (bool, int) {
-	resp, err := http.Get(checkUrl)
-	if err != nil {
-		// we swallow the error, because caller cares only about status
-		return false, 999
-	}
-	defer resp.Body.Close()
-
-	// we still dont care sites returning html content with with status code >=400
-	// e.g. Nginx 404/5xx
-	if resp.StatusCode >= 300 {
-		return false, resp.StatusCode
-	}
-	return true, resp.StatusCode
+// isHttpUrl returns true if the given url is an absolute http or https url with a host.
+func isHttpUrl(u *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && u.Host != ""
 }
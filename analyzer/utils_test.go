@@ -4,28 +4,6 @@ import (
 	"testing"
 )
 
-func TestConcatUrl(t *testing.T) {
-	testcases := []struct {
-		base     string
-		path     string
-		expected string
-	}{
-		{base: "", path: "", expected: ""},
-		{base: "www.a.com", path: "x/y/z", expected: "www.a.com/x/y/z"},
-		{base: "www.a.com", path: "/x/y/z", expected: "www.a.com/x/y/z"},
-		{base: "www.a.com/", path: "/x/y/z", expected: "www.a.com/x/y/z"},
-		{base: "www.a.com/", path: "", expected: "www.a.com/"},
-		{base: "www.a.com", path: "", expected: "www.a.com"},
-	}
-
-	for _, v := range testcases {
-		result := concatUrl(v.base, v.path)
-		if result != v.expected {
-			t.Fatalf("concatUrl() => Expected: %s, but recieved: %s", v.expected, result)
-		}
-	}
-}
-
 func TestGetFinalUrl(t *testing.T) {
 	testcases := []struct {
 		href      string
@@ -51,6 +29,11 @@ func TestGetFinalUrl(t *testing.T) {
 		{href: "relurl1", url: "https://www.a.com", expected: "https://www.a.com/relurl1", doesPanic: false},
 		{href: "/relurl1", url: "https://www.a.com/x/y", expected: "https://www.a.com/relurl1", doesPanic: false},
 		{href: "/relurl1", url: "https://www.a.com", expected: "https://www.a.com/relurl1", doesPanic: false},
+		{href: "mailto:a@a.com", url: "https://www.a.com", expected: "", doesPanic: true},
+		{href: "javascript:void(0)", url: "https://www.a.com", expected: "", doesPanic: true},
+		{href: "https://", url: "https://www.a.com", expected: "", doesPanic: true},
+		{href: "http://[::1", url: "https://www.a.com", expected: "", doesPanic: true},
+		{href: "x", url: "https://", expected: "", doesPanic: true},
 	}
 
 	for _, v := range testcases {
@@ -67,3 +50,83 @@ func TestGetFinalUrl(t *testing.T) {
 		}
 	}
 }
+
+func TestGetFinalUrl_Resolution(t *testing.T) {
+	testcases := map[string]struct {
+		href     string
+		url      string
+		expected string
+	}{
+		"Parent Directory":             {href: "../x", url: "https://www.a.com/p/q/r", expected: "https://www.a.com/p/x"},
+		"Multiple Parent Directories":  {href: "../../x", url: "https://www.a.com/p/q/r", expected: "https://www.a.com/x"},
+		"Parent Beyond Root":           {href: "../../../../x", url: "https://www.a.com/p/q", expected: "https://www.a.com/x"},
+		"Current Directory":            {href: "./x", url: "https://www.a.com/p/q", expected: "https://www.a.com/p/x"},
+		"Current Directory Only":       {href: ".", url: "https://www.a.com/p/q", expected: "https://www.a.com/p/"},
+		"Dot Segments In Middle":       {href: "a/./b/../c", url: "https://www.a.com/p/", expected: "https://www.a.com/p/a/c"},
+		"Directory Base":               {href: "x", url: "https://www.a.com/p/q/", expected: "https://www.a.com/p/q/x"},
+		"Protocol Relative":            {href: "//cdn.example.com/lib.js", url: "https://www.a.com/p", expected: "https://cdn.example.com/lib.js"},
+		"Protocol Relative Over Http":  {href: "//cdn.example.com/lib.js", url: "http://www.a.com/p", expected: "http://cdn.example.com/lib.js"},
+		"Query Only":                   {href: "?page=2", url: "https://www.a.com/p/list?page=1", expected: "https://www.a.com/p/list?page=2"},
+		"Query With Fragment Base":     {href: "?page=2", url: "https://www.a.com/list#top", expected: "https://www.a.com/list?page=2"},
+		"Fragment Keeps Query":         {href: "#top", url: "https://www.a.com/list?page=1", expected: "https://www.a.com/list?page=1#top"},
+		"Fragment Replaces Fragment":   {href: "#bottom", url: "https://www.a.com/list#top", expected: "https://www.a.com/list#bottom"},
+		"Root Relative Drops Query":    {href: "/x", url: "https://www.a.com/p?q=1", expected: "https://www.a.com/x"},
+		"Relative Keeps Port":          {href: "x", url: "http://localhost:8080/p/q", expected: "http://localhost:8080/p/x"},
+		"Surrounding Whitespace":       {href: "  /x \n", url: "https://www.a.com/p", expected: "https://www.a.com/x"},
+		"Uppercase Scheme":             {href: "HTTPS://www.b.com/x", url: "https://www.a.com", expected: "https://www.b.com/x"},
+		"Escaped Characters Preserved": {href: "a%20b/c", url: "https://www.a.com/p/", expected: "https://www.a.com/p/a%20b/c"},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			result, err := getFinalUrl(test.href, test.url)
+			if err != nil {
+				t.Fatalf("getFinalUrl('%s', '%s') => Not expected to throw an error! %v", test.href, test.url, err)
+			}
+			if result != test.expected {
+				t.Fatalf("getFinalUrl('%s', '%s') => Expected: %s, but recieved: %s", test.href, test.url, test.expected, result)
+			}
+		})
+	}
+}
+
+func TestLinkKindOf(t *testing.T) {
+	testcases := map[string]struct {
+		href               string
+		internalSubdomains bool
+		siblingDomains     []string
+		expected           string
+	}{
+		"Anchor":                        {href: "#top", expected: AnchorLink},
+		"Relative":                      {href: "about", expected: InternalLink},
+		"Root Relative":                 {href: "/about", expected: InternalLink},
+		"Absolute Same Host":            {href: "https://www.a.com/about", expected: InternalLink},
+		"Absolute Same Host Other Case": {href: "HTTP://WWW.A.COM/about", expected: InternalLink},
+		"Absolute Same Host With Port":  {href: "https://www.a.com:443/about", expected: InternalLink},
+		"Absolute Other Host":           {href: "https://www.b.com/about", expected: ExternalLink},
+		"Protocol Relative Same Host":   {href: "//www.a.com/about", expected: InternalLink},
+		"Protocol Relative Other Host":  {href: "//cdn.b.com/lib.js", expected: ExternalLink},
+		"Subdomain":                     {href: "https://docs.a.com", expected: ExternalLink},
+		"Subdomain As Internal":         {href: "https://docs.a.com", internalSubdomains: true, expected: InternalLink},
+		"Apex Domain As Internal":       {href: "https://a.com", internalSubdomains: true, expected: InternalLink},
+		"Similar Domain Not Internal":   {href: "https://nota.com", internalSubdomains: true, expected: ExternalLink},
+		"Sibling Domain":                {href: "https://a.org/x", siblingDomains: []string{"a.org"}, expected: InternalLink},
+		"Sibling Subdomain":             {href: "https://blog.a.org", siblingDomains: []string{"a.org"}, expected: ExternalLink},
+		"Sibling Subdomain As Internal": {href: "https://blog.a.org", internalSubdomains: true, siblingDomains: []string{"a.org"}, expected: InternalLink},
+		"Mailto":                        {href: "mailto:info@a.com", expected: MailtoLink},
+		"Mailto Upper Case":             {href: "MAILTO:info@a.com", expected: MailtoLink},
+		"Tel":                           {href: "tel:+123456", expected: TelLink},
+		"Javascript":                    {href: "javascript:void(0)", expected: JavascriptLink},
+		"Ftp":                           {href: "ftp://files.a.com", expected: OtherSchemeLink},
+		"Data":                          {href: "data:text/plain,hello", expected: OtherSchemeLink},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			result := linkKindOf(test.href, "https://www.a.com/p/q", "https://www.a.com/p/q", test.internalSubdomains, test.siblingDomains)
+			if result != test.expected {
+				t.Fatalf("linkKindOf('%s') => Expected: %s, but recieved: %s", test.href, test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"linklens/analyzer"
+	"linklens/server"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+)
+
+// Exit codes of the analyze command.
+const (
+	exitOk = 0
+	// number of broken links exceeds the given threshold.
+	exitBrokenLinks = 1
+	// invalid arguments or the analysis failed.
+	exitError = 2
+)
+
+// runAnalyze runs the analyze command with the given arguments, and prints the
+// analysis to the stdout. It returns the exit code the program should exit with.
+func runAnalyze(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: linklens analyze [flags] <url>")
+		fs.PrintDefaults()
+	}
+
+	var output string
+	var maxBroken int
+	var timeout time.Duration
+	var verbose bool
+	var siblingDomains, inspectors string
+	crawl := server.CrawlOptions{}
+	verifyExternal := true
+	var linkTimeout time.Duration
+	fs.StringVar(&output, "output", tableOutput, "Output format. One of table, json or yaml")
+	fs.IntVar(&maxBroken, "maxBroken", 0, "Maximum number of broken links allowed before exiting with a non-zero code. Negative disables the check")
+	fs.DurationVar(&timeout, "timeout", 0, "Timeout for the whole analysis (e.g. 2m). Partial results are reported on timeout")
+	fs.BoolVar(&verbose, "verbose", false, "Print progress logs to stderr")
+	fs.StringVar(&crawl.Strategy, "strategy", server.OneDepthStrategy, "Crawl strategy. One of oneDepth or depth")
+	fs.IntVar(&crawl.MaxDepth, "maxDepth", 0, "Maximum depth to crawl when using the depth strategy")
+	fs.IntVar(&crawl.MaxLinks, "maxLinks", 0, "Maximum number of links to verify per page. Zero means no limit")
+	fs.BoolVar(&verifyExternal, "verifyExternal", true, "Verify external links or not?")
+	fs.DurationVar(&linkTimeout, "linkTimeout", 0, "Timeout for each request sent to verify a single link (e.g. 5s)")
+	fs.BoolVar(&crawl.InternalSubdomains, "internalSubdomains", false, "Count links to subdomains as internal links")
+	fs.StringVar(&siblingDomains, "siblingDomains", "", "Comma separated list of other domains counted as internal links")
+	fs.StringVar(&inspectors, "inspectors", "", "Comma separated list of inspectors to run (e.g. title,links). Runs all inspectors if empty")
+	newAnalyzer := analyzerFlags(fs, "none")
+
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+	if output != tableOutput && output != jsonOutput && output != yamlOutput {
+		fmt.Fprintf(stderr, "unknown output format! %s\n", output)
+		return exitError
+	}
+
+	crawl.VerifyExternal = &verifyExternal
+	crawl.LinkTimeoutMs = int(linkTimeout.Milliseconds())
+	if siblingDomains != "" {
+		crawl.SiblingDomains = strings.Split(siblingDomains, ",")
+	}
+	if err := crawl.Validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	if !verbose {
+		slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	}
+
+	// interrupting the analysis still reports links verified so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	a, err := newAnalyzer()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	if inspectors != "" {
+		names := strings.Split(inspectors, ",")
+		for _, name := range names {
+			if !slices.Contains(a.InspectorNames(), name) {
+				fmt.Fprintf(stderr, "unknown inspector! %s\n", name)
+				return exitError
+			}
+		}
+		a = a.WithInspectors(names...)
+	}
+
+	info, err := a.AnalyzeUrl(ctx, fs.Arg(0), crawl.NewCrawler())
+	if err != nil {
+		var analysisErr *analyzer.AnalysisError
+		if errors.As(err, &analysisErr) {
+			fmt.Fprintf(stderr, "analysis failed! %s: %v\n", analysisErr.ErrorCode, analysisErr.Cause)
+		} else {
+			fmt.Fprintf(stderr, "analysis failed! %v\n", err)
+		}
+		return exitError
+	}
+
+	if err := printAnalysis(stdout, info, output); err != nil {
+		fmt.Fprintf(stderr, "unable to print the analysis! %v\n", err)
+		return exitError
+	}
+
+	if maxBroken >= 0 && info.LinkStats.InvalidLinkCount > maxBroken {
+		fmt.Fprintf(stderr, "found %d broken links, which exceeds the allowed maximum of %d\n", info.LinkStats.InvalidLinkCount, maxBroken)
+		return exitBrokenLinks
+	}
+	return exitOk
+}
+
+// printAnalysis writes the analysis to the given writer in the given output format.
+func printAnalysis(w io.Writer, info *analyzer.AnalysisData, output string) error {
+	switch output {
+	case jsonOutput:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	case yamlOutput:
+		return printYaml(w, info)
+	default:
+		return printTable(w, info)
+	}
+}
+
+// printYaml writes the analysis as yaml using the same field names as in json.
+func printYaml(w io.Writer, info *analyzer.AnalysisData) error {
+	content, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	// json is valid yaml, and decoding into a node preserves the order of fields.
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return err
+	}
+	resetYamlStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetYamlStyle clears the json flow style and quoting, so nodes are written in block style.
+func resetYamlStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYamlStyle(child)
+	}
+}
+
+// formatDoctype describes the html version along with the variant and the rendering mode.
+// e.g. 4.01 (Transitional, LimitedQuirks mode)
+func formatDoctype(doctype analyzer.Doctype) string {
+	details := []string{doctype.Variant}
+	if !doctype.Present {
+		details[0] = "no doctype"
+	}
+	if details[0] == "" {
+		details = nil
+	}
+	details = append(details, doctype.Mode+" mode")
+	return fmt.Sprintf("%s (%s)", doctype.Version, strings.Join(details, ", "))
+}
+
+// printOutline writes the given headings indented by their depth in the outline.
+func printOutline(w io.Writer, headings []analyzer.Heading, depth int) {
+	for _, heading := range headings {
+		text := heading.Text
+		if text == "" {
+			text = "(empty)"
+		}
+		skipped := ""
+		if heading.SkippedLevels > 0 {
+			skipped = fmt.Sprintf(" [skipped %d level(s)]", heading.SkippedLevels)
+		}
+		fmt.Fprintf(w, "%sH%d %s%s\n", strings.Repeat("  ", depth), heading.Level, text, skipped)
+		printOutline(w, heading.Children, depth+1)
+	}
+}
+
+// printTable writes a human readable summary of the analysis.
+func printTable(w io.Writer, info *analyzer.AnalysisData) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	stats := info.LinkStats
+
+	fmt.Fprintf(tw, "Source URL\t%s\n", info.SourceUrl)
+	fmt.Fprintf(tw, "HTML Version\t%s\n", formatDoctype(info.Doctype))
+	fmt.Fprintf(tw, "Title\t%s\n", strings.TrimSpace(info.Title))
+	if info.PageTypeConfidence > 0 {
+		fmt.Fprintf(tw, "Page Type\t%s (%.0f%% confident: %s)\n", info.PageType, info.PageTypeConfidence*100, strings.Join(info.PageTypeSignals, ", "))
+	} else {
+		fmt.Fprintf(tw, "Page Type\t%s\n", info.PageType)
+	}
+
+	if info.Metadata.Description != "" {
+		fmt.Fprintf(tw, "Description\t%s\n", info.Metadata.Description)
+	}
+	if info.Metadata.Canonical != "" {
+		fmt.Fprintf(tw, "Canonical\t%s\n", info.Metadata.Canonical)
+	}
+
+	fmt.Fprintln(tw, "\nHEADINGS")
+	headings := make([]string, 0, len(info.HeadingsCount))
+	for heading := range info.HeadingsCount {
+		headings = append(headings, heading)
+	}
+	slices.Sort(headings)
+	for _, heading := range headings {
+		fmt.Fprintf(tw, "%s\t%d\n", heading, info.HeadingsCount[heading])
+	}
+
+	if len(info.Outline) > 0 {
+		fmt.Fprintln(tw, "\nOUTLINE")
+		printOutline(tw, info.Outline, 0)
+	}
+
+	fmt.Fprintln(tw, "\nLINKS")
+	fmt.Fprintf(tw, "Internal\t%d\n", stats.InternalLinkCount)
+	fmt.Fprintf(tw, "External\t%d\n", stats.ExternalLinkCount)
+	fmt.Fprintf(tw, "Mailto\t%d\n", stats.MailtoLinkCount)
+	fmt.Fprintf(tw, "Tel\t%d\n", stats.TelLinkCount)
+	fmt.Fprintf(tw, "Javascript\t%d\n", stats.JavascriptLinkCount)
+	fmt.Fprintf(tw, "Other Schemes\t%d\n", stats.OtherSchemeLinkCount)
+	fmt.Fprintf(tw, "Redirected\t%d\n", stats.RedirectedLinkCount)
+	fmt.Fprintf(tw, "Broken\t%d\n", stats.InvalidLinkCount)
+	if stats.BlockedLinkCount > 0 {
+		fmt.Fprintf(tw, "Blocked by Robots\t%d\n", stats.BlockedLinkCount)
+	}
+	if stats.Incomplete {
+		fmt.Fprintln(tw, "Incomplete\tyes, not all links were verified")
+	}
+
+	if len(info.SeoIssues) > 0 {
+		fmt.Fprintln(tw, "\nSEO ISSUES")
+		for _, issue := range info.SeoIssues {
+			fmt.Fprintf(tw, "%s\t%s\n", issue.Code, issue.Message)
+		}
+	}
+
+	if len(info.AccessibilityIssues) > 0 {
+		fmt.Fprintln(tw, "\nACCESSIBILITY ISSUES")
+		for _, issue := range info.AccessibilityIssues {
+			message := issue.Message
+			if issue.Element != "" {
+				message += ": " + issue.Element
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", issue.Code, message)
+		}
+	}
+
+	resources := info.ResourceStats
+	if resources.ResourceCount > 0 {
+		fmt.Fprintln(tw, "\nRESOURCES")
+		fmt.Fprintln(tw, "TYPE\tCOUNT\tBROKEN")
+		types := make([]string, 0, len(resources.Types))
+		for resourceType := range resources.Types {
+			types = append(types, resourceType)
+		}
+		slices.Sort(types)
+		for _, resourceType := range types {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", resourceType, resources.Types[resourceType].Count, resources.Types[resourceType].BrokenCount)
+		}
+		if resources.Incomplete {
+			fmt.Fprintln(tw, "Incomplete\tyes, not all resources were verified")
+		}
+	}
+
+	if len(info.Forms) > 0 {
+		fmt.Fprintln(tw, "\nFORMS")
+		fmt.Fprintln(tw, "METHOD\tACTION\tFIELDS\tISSUES")
+		for _, form := range info.Forms {
+			issues := []string{}
+			if form.InsecurePassword {
+				issues = append(issues, "password over http")
+			}
+			if form.MissingCsrfToken {
+				issues = append(issues, "no csrf token")
+			}
+			if form.CrossOriginAction {
+				issues = append(issues, "cross-origin action")
+			}
+			if len(issues) == 0 {
+				issues = append(issues, "-")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", form.Method, form.Action, len(form.Fields), strings.Join(issues, ", "))
+		}
+	}
+
+	if stats.InvalidLinkCount > 0 {
+		fmt.Fprintln(tw, "\nBROKEN LINKS")
+		fmt.Fprintln(tw, "URL\tSTATUS\tREASON")
+		for _, link := range stats.Links {
+			if link.Verified && !link.IsValid {
+				url := link.Url
+				if url == "" {
+					url = link.Href
+				}
+				fmt.Fprintf(tw, "%s\t%d\t%s\n", url, link.StatusCode, link.ErrorCategory)
+			}
+		}
+	}
+
+	if resources.BrokenResourceCount > 0 {
+		fmt.Fprintln(tw, "\nBROKEN RESOURCES")
+		fmt.Fprintln(tw, "URL\tTYPE\tSTATUS\tREASON")
+		for _, resource := range resources.Resources {
+			if resource.Verified && !resource.IsValid {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", resource.Url, resource.Type, resource.StatusCode, resource.ErrorCategory)
+			}
+		}
+	}
+	return tw.Flush()
+}
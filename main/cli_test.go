@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"linklens/analyzer"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const cliTestPage = `<html><head><title>CLI</title></head><body>
+	<h1>Links</h1>
+	<a href="/ok">Ok</a>
+	<a href="/broken">Broken</a>
+	<img src="/logo.png"><img src="/missing.png">
+</body></html>`
+
+func mockCliSite() {
+	gock.New("https://cli.test").Get("/").Persist().Reply(200).SetHeader("content-type", "text/html").BodyString(cliTestPage)
+	gock.New("https://cli.test").Path("/ok").Persist().Reply(200)
+	gock.New("https://cli.test").Path("/broken").Persist().Reply(404)
+	gock.New("https://cli.test").Path("/logo.png").Persist().Reply(200)
+	gock.New("https://cli.test").Path("/missing.png").Persist().Reply(404)
+}
+
+func TestRunAnalyze_ExitCodes(t *testing.T) {
+	defer gock.Off()
+	mockCliSite()
+
+	cases := map[string]struct {
+		args     []string
+		exitCode int
+	}{
+		"Broken Links Exceed Default Threshold": {args: []string{"https://cli.test"}, exitCode: exitBrokenLinks},
+		"Broken Links Within Threshold":         {args: []string{"-maxBroken", "1", "https://cli.test"}, exitCode: exitOk},
+		"Threshold Disabled":                    {args: []string{"-maxBroken", "-1", "https://cli.test"}, exitCode: exitOk},
+		"Missing Url":                           {args: []string{}, exitCode: exitError},
+		"Unknown Output":                        {args: []string{"-output", "xml", "https://cli.test"}, exitCode: exitError},
+		"Invalid Crawl Options":                 {args: []string{"-maxDepth", "2", "https://cli.test"}, exitCode: exitError},
+		"Failed Analysis":                       {args: []string{"https://missing.example"}, exitCode: exitError},
+		"Unknown Inspector":                     {args: []string{"-inspectors", "title,random", "https://cli.test"}, exitCode: exitError},
+		"Links Not Inspected":                   {args: []string{"-inspectors", "title,seo", "https://cli.test"}, exitCode: exitOk},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			var stdout, stderr bytes.Buffer
+			exitCode := runAnalyze(tc.args, &stdout, &stderr)
+
+			// THEN
+			assert.Equal(t, tc.exitCode, exitCode, stderr.String())
+		})
+	}
+}
+
+func TestRunAnalyze_Outputs(t *testing.T) {
+	defer gock.Off()
+	mockCliSite()
+
+	t.Run("Json", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		runAnalyze([]string{"-output", "json", "https://cli.test"}, &stdout, &stderr)
+
+		var info analyzer.AnalysisData
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &info))
+		assert.Equal(t, "CLI", info.Title)
+		assert.Equal(t, 1, info.LinkStats.InvalidLinkCount)
+		assert.Equal(t, []string{"https://cli.test/broken"}, info.LinkStats.InvalidLinks)
+	})
+
+	t.Run("Yaml", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		runAnalyze([]string{"-output", "yaml", "https://cli.test"}, &stdout, &stderr)
+
+		// yaml must use the same field names as json.
+		var info analyzer.AnalysisData
+		assert.Contains(t, stdout.String(), "SourceUrl: https://cli.test\n")
+		assert.Contains(t, stdout.String(), "  InvalidLinkCount: 1\n")
+		assert.NoError(t, json.Unmarshal(yamlToJson(t, stdout.Bytes()), &info))
+		assert.Equal(t, map[string]int{"H1": 1}, info.HeadingsCount)
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		runAnalyze([]string{"https://cli.test"}, &stdout, &stderr)
+
+		assert.Contains(t, stdout.String(), "HTML Version  Unknown (no doctype, Quirks mode)\n")
+		assert.Contains(t, stdout.String(), "Title         CLI\n")
+		assert.Contains(t, stdout.String(), "Broken         1\n")
+		assert.Contains(t, stdout.String(), "https://cli.test/broken  404     HttpStatusError\n")
+		assert.Contains(t, stdout.String(), "Image  2      1\n")
+		assert.Contains(t, stdout.String(), "MissingDescription  page has no meta description\n")
+		assert.Contains(t, stdout.String(), "\nOUTLINE\nH1 Links\n")
+		assert.Contains(t, stdout.String(), "MissingLang      html element has no lang attribute\n")
+		assert.Contains(t, stdout.String(), "https://cli.test/missing.png  Image  404     HttpStatusError\n")
+	})
+}
+
+func yamlToJson(t *testing.T, content []byte) []byte {
+	var value map[string]any
+	assert.NoError(t, yaml.Unmarshal(content, &value))
+	result, err := json.Marshal(value)
+	assert.NoError(t, err)
+	return result
+}
@@ -3,29 +3,54 @@ package main
 import (
 	"flag"
 	"fmt"
+	"linklens/analyzer"
 	"linklens/server"
 	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		os.Exit(runAnalyze(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	var webDir string
 	var port int
 	var serveUI bool
+	var maxJobs int
+	var jobRetention time.Duration
 	flag.BoolVar(&serveUI, "ui", true, "Serve the UI or not?")
 	flag.StringVar(&webDir, "webDir", "./web/build", "Directory path to the web artifacts")
 	flag.IntVar(&port, "port", 8080, "Port for the service")
+	flag.IntVar(&maxJobs, "maxJobs", server.DefaultMaxRunningJobs, "Maximum number of analysis jobs running at the same time")
+	flag.DurationVar(&jobRetention, "jobRetention", server.DefaultJobRetention, "Duration to keep results of finished jobs (e.g. 30m)")
+	newAnalyzer := analyzerFlags(flag.CommandLine, "memory")
 	flag.Parse()
 
+	a, err := newAnalyzer()
+	if err != nil {
+		slog.Error("Invalid configurations!", "error", err)
+		os.Exit(2)
+	}
 	r := mux.NewRouter()
 
 	slog.Info("Registering end points:")
 	contextPath := "/api"
 	// register routes
 	server.HealthEndPoint(contextPath).Register(r)
-	server.AnalyzeEndPoint(contextPath).Register(r)
+	server.AnalyzeEndPoint(contextPath, a).Register(r)
+	server.AnalyzeStreamEndPoint(contextPath, a).Register(r)
+	server.BatchAnalyzeEndPoint(contextPath, a).Register(r)
+	jobs := server.NewJobManager(a, maxJobs, jobRetention)
+	server.SubmitJobEndPoint(contextPath, jobs).Register(r)
+	server.GetJobEndPoint(contextPath, jobs).Register(r)
+	server.CancelJobEndPoint(contextPath, jobs).Register(r)
 
 	// serve UI?
 	if serveUI {
@@ -36,8 +61,108 @@ func main() {
 
 	// start server
 	slog.Info("Service is listening on ", "port", port)
-	err := http.ListenAndServe(fmt.Sprintf(":%d", port), r)
+	err = http.ListenAndServe(fmt.Sprintf(":%d", port), r)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error occurred while loading server: %v", err))
 	}
 }
+
+// analyzerFlags registers the flags configuring the analyzer in the given flag set,
+// and returns a function to create the analyzer once flags are parsed.
+func analyzerFlags(fs *flag.FlagSet, defaultCache string) func() (*analyzer.Analyzer, error) {
+	var options analyzer.Options
+	var cacheType, cacheDir string
+	var cacheSize int
+	var cacheTTL time.Duration
+	headers := headerFlags{}
+	fs.DurationVar(&options.ConnectTimeout, "connectTimeout", 0, "Timeout for establishing connections (e.g. 5s)")
+	fs.DurationVar(&options.ReadTimeout, "readTimeout", 0, "Timeout for receiving response headers (e.g. 10s)")
+	fs.IntVar(&options.MaxRedirects, "maxRedirects", analyzer.DefaultMaxRedirects, "Maximum number of redirects to follow. 0 uses the default, negative treats every redirect as an error")
+	fs.StringVar(&options.UserAgent, "userAgent", analyzer.DefaultUserAgent, "User-Agent header sent with all requests")
+	fs.Var(headers, "header", "Extra header sent with all requests in 'Name: Value' format. Can be repeated.")
+	fs.BoolVar(&options.IgnoreRobots, "ignoreRobots", false, "Ignore robots.txt rules and crawl delays of the sites")
+	fs.Var((*hostFlags)(&options.RobotsExemptHosts), "robotsExemptHost", "Host whose robots.txt is ignored, e.g. a staging site you own. Can be repeated.")
+	fs.IntVar(&options.Retry.MaxAttempts, "retryAttempts", 3, "Maximum number of attempts to verify a link failed due to transient errors. 1 disables retries")
+	fs.DurationVar(&options.Retry.Backoff, "retryBackoff", analyzer.DefaultRetryBackoff, "Delay before the first retry, doubled on each retry (e.g. 500ms)")
+	fs.DurationVar(&options.Retry.MaxBackoff, "retryMaxBackoff", analyzer.DefaultMaxRetryBackoff, "Maximum delay between retries, including delays asked by Retry-After headers")
+	fs.Func("retryStatus", "Comma separated status codes to retry (default 429,502,503,504)", func(value string) error {
+		codes, err := parseStatusCodes(value)
+		options.Retry.StatusCodes = codes
+		return err
+	})
+	fs.Float64Var(&options.HostRateLimit, "hostRateLimit", 0, "Maximum number of requests per second sent to a single host. 0 means no limit")
+	fs.StringVar(&cacheType, "cache", defaultCache, "Cache of analysis results and link statuses. One of memory, disk or none")
+	fs.StringVar(&cacheDir, "cacheDir", "./.linklens-cache", "Directory to store cached entries, when disk cache is used")
+	fs.IntVar(&cacheSize, "cacheSize", analyzer.DefaultCacheCapacity, "Maximum number of entries kept in the memory cache")
+	fs.DurationVar(&cacheTTL, "cacheTTL", analyzer.DefaultCacheTTL, "Duration to keep cached entries (e.g. 10m)")
+
+	return func() (*analyzer.Analyzer, error) {
+		options.Headers = headers
+		switch cacheType {
+		case "memory":
+			options.Cache = analyzer.NewMemoryCache(cacheSize, cacheTTL)
+		case "disk":
+			cache, err := analyzer.NewDiskCache(cacheDir, cacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create the disk cache! %w", err)
+			}
+			options.Cache = cache
+		case "none":
+		default:
+			return nil, fmt.Errorf("unknown cache type! %s", cacheType)
+		}
+		return analyzer.NewAnalyzer(options), nil
+	}
+}
+
+// headerFlags collects repeated header arguments in 'Name: Value' format.
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	pairs := []string{}
+	for k, v := range h {
+		pairs = append(pairs, k+": "+v)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (h headerFlags) Set(value string) error {
+	name, val, found := strings.Cut(value, ":")
+	if !found || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header must be in 'Name: Value' format")
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(val)
+	return nil
+}
+
+// hostFlags collects repeated host arguments.
+type hostFlags []string
+
+func (h *hostFlags) String() string {
+	if h == nil {
+		return ""
+	}
+	return strings.Join(*h, ", ")
+}
+
+func (h *hostFlags) Set(value string) error {
+	host := strings.TrimSpace(value)
+	if host == "" || strings.ContainsAny(host, "/: ") {
+		return fmt.Errorf("host must be a domain name without a scheme or port")
+	}
+	*h = append(*h, host)
+	return nil
+}
+
+// parseStatusCodes parses the given comma separated list of http status codes.
+func parseStatusCodes(value string) ([]int, error) {
+	codes := []int{}
+	for _, part := range strings.Split(value, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code! %s", part)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"linklens/analyzer"
+)
+
+// Validate returns a ValidationError if the batch request is not acceptable.
+func (r *BatchAnalyzeRequest) Validate() error {
+	if len(r.Urls) == 0 && r.SitemapUrl == "" {
+		return &ValidationError{
+			ErrorCode: InvalidBatchRequest,
+			Field:     "urls",
+			Message:   "either urls or a sitemap url must be given",
+		}
+	} else if len(r.Urls) > 0 && r.SitemapUrl != "" {
+		return &ValidationError{
+			ErrorCode: InvalidBatchRequest,
+			Field:     "sitemapUrl",
+			Message:   "urls and sitemap url cannot be given together",
+		}
+	} else if len(r.Urls) > MaxAllowedBatchUrls {
+		return &ValidationError{
+			ErrorCode: InvalidBatchRequest,
+			Field:     "urls",
+			Message:   fmt.Sprintf("maximum of %d urls can be analyzed in a batch", MaxAllowedBatchUrls),
+		}
+	}
+
+	for _, url := range r.Urls {
+		if url == "" {
+			return &ValidationError{
+				ErrorCode: InvalidBatchRequest,
+				Field:     "urls",
+				Message:   "urls cannot be empty",
+			}
+		}
+	}
+	return r.Crawl.Validate()
+}
+
+// newBatchResult converts the analyzer result of a single url to the response.
+func newBatchResult(result analyzer.BatchResult) BatchResult {
+	if result.Error == nil {
+		return BatchResult{Url: result.Url, Data: result.Data}
+	}
+	return BatchResult{Url: result.Url, Error: newErrorDetail(result.Error)}
+}
+
+// newErrorDetail converts the given analysis error to the response.
+func newErrorDetail(err error) *ErrorDetail {
+	var analysisErr *analyzer.AnalysisError
+	if errors.As(err, &analysisErr) {
+		return &ErrorDetail{ErrorCode: analysisErr.ErrorCode, Message: analysisErr.Cause.Error()}
+	}
+	return &ErrorDetail{Message: err.Error()}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"linklens/analyzer"
+	"strings"
+	"time"
+)
+
+// Validate returns a ValidationError if any of the crawl options is not acceptable.
+func (o *CrawlOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if o.Strategy != "" && o.Strategy != OneDepthStrategy && o.Strategy != DepthStrategy {
+		return &ValidationError{
+			ErrorCode: InvalidCrawlOptions,
+			Field:     "crawl.strategy",
+			Message:   fmt.Sprintf("unknown strategy! supported strategies are %s and %s", OneDepthStrategy, DepthStrategy),
+		}
+	} else if o.MaxDepth < 0 || o.MaxDepth > MaxAllowedDepth {
+		return &ValidationError{
+			ErrorCode: InvalidCrawlOptions,
+			Field:     "crawl.maxDepth",
+			Message:   fmt.Sprintf("max depth must be between 0 and %d", MaxAllowedDepth),
+		}
+	} else if o.MaxDepth > 0 && o.Strategy != DepthStrategy {
+		return &ValidationError{
+			ErrorCode: InvalidCrawlOptions,
+			Field:     "crawl.maxDepth",
+			Message:   fmt.Sprintf("max depth is only supported with %s strategy", DepthStrategy),
+		}
+	} else if o.MaxLinks < 0 {
+		return &ValidationError{
+			ErrorCode: InvalidCrawlOptions,
+			Field:     "crawl.maxLinks",
+			Message:   "max links cannot be negative",
+		}
+	} else if o.LinkTimeoutMs < 0 || o.LinkTimeoutMs > MaxAllowedLinkTimeoutMs {
+		return &ValidationError{
+			ErrorCode: InvalidCrawlOptions,
+			Field:     "crawl.linkTimeoutMs",
+			Message:   fmt.Sprintf("link timeout must be between 0 and %d milliseconds", MaxAllowedLinkTimeoutMs),
+		}
+	}
+
+	for _, domain := range o.SiblingDomains {
+		if domain == "" || strings.ContainsAny(domain, "/: ") {
+			return &ValidationError{
+				ErrorCode: InvalidCrawlOptions,
+				Field:     "crawl.siblingDomains",
+				Message:   fmt.Sprintf("sibling domain must be a plain domain name without scheme or path! %q", domain),
+			}
+		}
+	}
+	return nil
+}
+
+// NewCrawler creates the crawler matching to the crawl options.
+// Options must be validated before calling this.
+func (o *CrawlOptions) NewCrawler() analyzer.Crawler {
+	if o == nil {
+		return &analyzer.OneDepthCrawler{}
+	}
+
+	config := analyzer.CrawlConfig{
+		MaxLinks:     o.MaxLinks,
+		SkipExternal: o.VerifyExternal != nil && !*o.VerifyExternal,
+		LinkTimeout:  time.Duration(o.LinkTimeoutMs) * time.Millisecond,
+
+		InternalSubdomains: o.InternalSubdomains,
+		SiblingDomains:     o.SiblingDomains,
+	}
+
+	if o.Strategy == DepthStrategy {
+		return &analyzer.DepthCrawler{CrawlConfig: config, MaxDepth: o.MaxDepth}
+	}
+	return &analyzer.OneDepthCrawler{CrawlConfig: config}
+}
@@ -6,6 +6,9 @@ import (
 	"linklens/analyzer"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -36,7 +39,7 @@ func HealthEndPoint(contextPath string) RouteHandler {
 	}
 }
 
-func AnalyzeEndPoint(contextPath string) RouteHandler {
+func AnalyzeEndPoint(contextPath string, a *analyzer.Analyzer) RouteHandler {
 	return RouteHandler{
 		RouteDef: func(r *mux.Route) string {
 			r.Path(contextPath + "/analyze").Methods("POST")
@@ -47,31 +50,247 @@ func AnalyzeEndPoint(contextPath string) RouteHandler {
 			err := json.NewDecoder(r.Body).Decode(&req)
 
 			if err != nil {
-				slog.Error("Error decoding request: %v", err)
+				slog.Error("Error decoding request!", "error", err)
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			} else if req.Url == "" {
 				slog.Error("Analyze URL cannot be empty! Url!")
 				http.Error(w, "Empty URL", http.StatusBadRequest)
 				return
+			} else if err := req.Crawl.Validate(); err != nil {
+				handleValidationError(err, w)
+				return
+			} else if err := validateInspectors(a, req.Inspectors); err != nil {
+				handleValidationError(err, w)
+				return
 			}
 
-			// We could control the crawl behaviour may be using another field from request body.
-			// So, a client may be able to specify how many depths should traverse.
-			crawler := &analyzer.OneDepthCrawler{}
-			result, err := analyzer.AnalyzeUrl(req.Url, crawler)
+			result, err := analyzerOf(a, req.NoCache, req.Inspectors).AnalyzeUrl(r.Context(), req.Url, req.Crawl.NewCrawler())
 			if err != nil {
 				handleAnalysisError(err, w)
 				return
 			}
 
 			content, _ := json.Marshal(result)
+			setCacheHeaders(w, result)
+			w.WriteHeader(http.StatusOK)
+			logErrIf(w.Write(content))
+		},
+	}
+}
+
+func BatchAnalyzeEndPoint(contextPath string, a *analyzer.Analyzer) RouteHandler {
+	return RouteHandler{
+		RouteDef: func(r *mux.Route) string {
+			r.Path(contextPath + "/analyze/batch").Methods("POST")
+			return fmt.Sprintf("%s: %s%s", "POST", contextPath, "/analyze/batch")
+		},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			var req BatchAnalyzeRequest
+			err := json.NewDecoder(r.Body).Decode(&req)
+
+			if err != nil {
+				slog.Error("Error decoding request!", "error", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			} else if err := req.Validate(); err != nil {
+				handleValidationError(err, w)
+				return
+			} else if err := validateInspectors(a, req.Inspectors); err != nil {
+				handleValidationError(err, w)
+				return
+			}
+
+			res := BatchAnalyzeResponse{Results: []BatchResult{}}
+			urls := req.Urls
+			if req.SitemapUrl != "" {
+				urls, res.Truncated, err = a.FetchSitemap(r.Context(), req.SitemapUrl, MaxAllowedBatchUrls)
+				if err != nil {
+					handleAnalysisError(err, w)
+					return
+				} else if res.Truncated {
+					slog.Warn("Sitemap has too many urls! Only the first urls will be analyzed.", "sitemap", req.SitemapUrl, "urls#", len(urls))
+				}
+			}
+
+			for _, result := range analyzerOf(a, req.NoCache, req.Inspectors).AnalyzeUrls(r.Context(), urls, req.Crawl.NewCrawler()) {
+				res.Results = append(res.Results, newBatchResult(result))
+			}
+
+			content, _ := json.Marshal(res)
+			w.WriteHeader(http.StatusOK)
+			logErrIf(w.Write(content))
+		},
+	}
+}
+
+// AnalyzeStreamEndPoint analyzes the url given in the query, and streams the progress
+// as server-sent events. The stream ends with either a completed or a failed event.
+func AnalyzeStreamEndPoint(contextPath string, a *analyzer.Analyzer) RouteHandler {
+	return RouteHandler{
+		RouteDef: func(r *mux.Route) string {
+			r.Path(contextPath + "/analyze/stream").Methods("GET")
+			return fmt.Sprintf("%s: %s%s", "GET", contextPath, "/analyze/stream")
+		},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			crawl, err := crawlOptionsOf(query)
+
+			if query.Get("url") == "" {
+				slog.Error("Analyze URL cannot be empty! Url!")
+				http.Error(w, "Empty URL", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				handleValidationError(err, w)
+				return
+			} else if err := crawl.Validate(); err != nil {
+				handleValidationError(err, w)
+				return
+			} else if err := validateInspectors(a, query["inspectors"]); err != nil {
+				handleValidationError(err, w)
+				return
+			}
+
+			stream, ok := newEventStream(w)
+			if !ok {
+				slog.Error("Response does not support streaming!")
+				http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+				return
+			}
+
+			noCache, _ := strconv.ParseBool(query.Get("noCache"))
+			result, err := analyzerOf(a, noCache, query["inspectors"]).WithListener(stream.onProgress).AnalyzeUrl(r.Context(), query.Get("url"), crawl.NewCrawler())
+			if err != nil {
+				slog.Error(err.Error())
+				stream.send(FailedEvent, newErrorDetail(err))
+				return
+			}
+			stream.send(CompletedEvent, result)
+		},
+	}
+}
+
+func SubmitJobEndPoint(contextPath string, m *JobManager) RouteHandler {
+	return RouteHandler{
+		RouteDef: func(r *mux.Route) string {
+			r.Path(contextPath + "/jobs").Methods("POST")
+			return fmt.Sprintf("%s: %s%s", "POST", contextPath, "/jobs")
+		},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			var req AnalyzeRequest
+			err := json.NewDecoder(r.Body).Decode(&req)
+
+			if err != nil {
+				slog.Error("Error decoding request!", "error", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			} else if req.Url == "" {
+				slog.Error("Analyze URL cannot be empty! Url!")
+				http.Error(w, "Empty URL", http.StatusBadRequest)
+				return
+			} else if err := req.Crawl.Validate(); err != nil {
+				handleValidationError(err, w)
+				return
+			} else if err := validateInspectors(m.analyzer, req.Inspectors); err != nil {
+				handleValidationError(err, w)
+				return
+			}
+
+			res := m.Submit(req)
+			content, _ := json.Marshal(res)
+			w.Header().Set("Location", fmt.Sprintf("%s/jobs/%s", contextPath, res.Id))
+			w.WriteHeader(http.StatusAccepted)
+			logErrIf(w.Write(content))
+		},
+	}
+}
+
+func GetJobEndPoint(contextPath string, m *JobManager) RouteHandler {
+	return RouteHandler{
+		RouteDef: func(r *mux.Route) string {
+			r.Path(contextPath + "/jobs/{id}").Methods("GET")
+			return fmt.Sprintf("%s: %s%s", "GET", contextPath, "/jobs/{id}")
+		},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			res, ok := m.Get(mux.Vars(r)["id"])
+			if !ok {
+				handleJobNotFound(w)
+				return
+			}
+
+			content, _ := json.Marshal(res)
+			w.WriteHeader(http.StatusOK)
+			logErrIf(w.Write(content))
+		},
+	}
+}
+
+func CancelJobEndPoint(contextPath string, m *JobManager) RouteHandler {
+	return RouteHandler{
+		RouteDef: func(r *mux.Route) string {
+			r.Path(contextPath + "/jobs/{id}").Methods("DELETE")
+			return fmt.Sprintf("%s: %s%s", "DELETE", contextPath, "/jobs/{id}")
+		},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			res, ok := m.Cancel(mux.Vars(r)["id"])
+			if !ok {
+				handleJobNotFound(w)
+				return
+			}
+
+			content, _ := json.Marshal(res)
 			w.WriteHeader(http.StatusOK)
 			logErrIf(w.Write(content))
 		},
 	}
 }
 
+// analyzerOf returns the analyzer to use for a request, which bypasses the cache if asked,
+// and runs only the given inspectors, if any.
+func analyzerOf(a *analyzer.Analyzer, noCache bool, inspectors []string) *analyzer.Analyzer {
+	if noCache {
+		a = a.WithCacheBypass()
+	}
+	if len(inspectors) > 0 {
+		a = a.WithInspectors(inspectors...)
+	}
+	return a
+}
+
+// validateInspectors returns a ValidationError if any of the given inspectors is unknown to the analyzer.
+func validateInspectors(a *analyzer.Analyzer, inspectors []string) error {
+	names := a.InspectorNames()
+	for _, inspector := range inspectors {
+		if !slices.Contains(names, inspector) {
+			return &ValidationError{
+				ErrorCode: InvalidInspectors,
+				Field:     "inspectors",
+				Message:   fmt.Sprintf("unknown inspector %q! supported inspectors are %s", inspector, strings.Join(names, ", ")),
+			}
+		}
+	}
+	return nil
+}
+
+// setCacheHeaders indicates whether the result is served from the cache, and its age in seconds.
+func setCacheHeaders(w http.ResponseWriter, result *analyzer.AnalysisData) {
+	if result.Cache != nil && result.Cache.Hit {
+		w.Header().Set("X-Cache", "HIT")
+		w.Header().Set("Age", strconv.FormatInt(result.Cache.AgeMs/1000, 10))
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+}
+
+func handleJobNotFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	errObj, _ := json.Marshal(map[string]interface{}{
+		"errorCode": JobNotFound,
+		"message":   "no such job exists or it has been expired",
+	})
+	logErrIf(w.Write(errObj))
+}
+
 func handleAnalysisError(err error, w http.ResponseWriter) {
 	slog.Error(err.Error())
 
@@ -90,6 +309,27 @@ func handleAnalysisError(err error, w http.ResponseWriter) {
 	logErrIf(w.Write([]byte(errObj)))
 }
 
+func handleValidationError(err error, w http.ResponseWriter) {
+	slog.Error("Invalid request!", "error", err)
+
+	w.WriteHeader(http.StatusBadRequest)
+	var errObj []byte
+	e, ok := err.(*ValidationError)
+
+	if ok {
+		errObj, _ = json.Marshal(map[string]interface{}{
+			"errorCode": e.ErrorCode,
+			"field":     e.Field,
+			"message":   e.Message,
+		})
+	} else {
+		errObj, _ = json.Marshal(map[string]interface{}{
+			"message": err.Error(),
+		})
+	}
+	logErrIf(w.Write(errObj))
+}
+
 func logErrIf(n int, err error) {
 	if err != nil {
 		slog.Error("Error occurred while writing response!", "error", err)
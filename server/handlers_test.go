@@ -2,11 +2,15 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"linklens/analyzer"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -56,7 +60,7 @@ func TestAnalyze_Errors(t *testing.T) {
 
 	// GIVEN
 	r := mux.NewRouter()
-	AnalyzeEndPoint("/api").Register(r)
+	AnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
 
 	for name, test := range testcases {
 		t.Run(name, func(t *testing.T) {
@@ -79,7 +83,7 @@ func TestAnalyze_Errors(t *testing.T) {
 func TestAnalyze_400_InvalidURL(t *testing.T) {
 	// GIVEN
 	r := mux.NewRouter()
-	AnalyzeEndPoint("/api").Register(r)
+	AnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
 
 	t.Run("Invalid URL", func(t *testing.T) {
 		// WHEN
@@ -100,11 +104,143 @@ func TestAnalyze_400_InvalidURL(t *testing.T) {
 	})
 }
 
+func TestAnalyze_400_InvalidCrawlOptions(t *testing.T) {
+	testcases := map[string]struct {
+		requestBody string
+		field       string
+	}{
+		"Unknown Strategy": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "strategy": "random" } }`,
+			field:       "crawl.strategy",
+		},
+		"Negative Depth": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "strategy": "depth", "maxDepth": -1 } }`,
+			field:       "crawl.maxDepth",
+		},
+		"Too Deep": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "strategy": "depth", "maxDepth": 100 } }`,
+			field:       "crawl.maxDepth",
+		},
+		"Depth Without Depth Strategy": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "maxDepth": 2 } }`,
+			field:       "crawl.maxDepth",
+		},
+		"Negative Max Links": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "maxLinks": -5 } }`,
+			field:       "crawl.maxLinks",
+		},
+		"Sibling Domain With Scheme": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "siblingDomains": ["https://google.org"] } }`,
+			field:       "crawl.siblingDomains",
+		},
+		"Too Long Timeout": {
+			requestBody: `{ "url": "https://www.google.com", "crawl": { "linkTimeoutMs": 600000 } }`,
+			field:       "crawl.linkTimeoutMs",
+		},
+	}
+
+	// GIVEN
+	r := mux.NewRouter()
+	AnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", strings.NewReader(test.requestBody)))
+
+			// THEN
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected to have status code 400! Actual: %d", w.Code)
+			}
+			var errObj map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&errObj); err != nil {
+				t.Errorf("Expected to return a structured error object! Received: %s", err.Error())
+			}
+			if errObj["errorCode"] != InvalidCrawlOptions {
+				t.Errorf("Expected to return %s error code, but got %s", InvalidCrawlOptions, errObj["errorCode"])
+			} else if errObj["field"] != test.field {
+				t.Errorf("Expected to report field %s, but got %s", test.field, errObj["field"])
+			}
+		})
+	}
+}
+
+func TestAnalyze_400_InvalidInspectors(t *testing.T) {
+	// GIVEN
+	r := mux.NewRouter()
+	AnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", strings.NewReader(`{ "url": "https://www.google.com", "inspectors": ["title", "random"] }`)))
+
+	// THEN
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected to have status code 400! Actual: %d", w.Code)
+	}
+	var errObj map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&errObj); err != nil {
+		t.Errorf("Expected to return a structured error object! Received: %s", err.Error())
+	}
+	if errObj["errorCode"] != InvalidInspectors {
+		t.Errorf("Expected to return %s error code, but got %s", InvalidInspectors, errObj["errorCode"])
+	} else if errObj["field"] != "inspectors" {
+		t.Errorf("Expected to report field inspectors, but got %s", errObj["field"])
+	}
+}
+
+func TestCrawlOptions_NewCrawler(t *testing.T) {
+	verifyExternal := false
+	testcases := map[string]struct {
+		options  *CrawlOptions
+		expected analyzer.Crawler
+	}{
+		"No Options": {
+			options:  nil,
+			expected: &analyzer.OneDepthCrawler{},
+		},
+		"One Depth Strategy": {
+			options: &CrawlOptions{Strategy: OneDepthStrategy, MaxLinks: 10, LinkTimeoutMs: 1500},
+			expected: &analyzer.OneDepthCrawler{CrawlConfig: analyzer.CrawlConfig{
+				MaxLinks:    10,
+				LinkTimeout: 1500 * time.Millisecond,
+			}},
+		},
+		"Depth Strategy": {
+			options: &CrawlOptions{Strategy: DepthStrategy, MaxDepth: 2, VerifyExternal: &verifyExternal},
+			expected: &analyzer.DepthCrawler{
+				CrawlConfig: analyzer.CrawlConfig{SkipExternal: true},
+				MaxDepth:    2,
+			},
+		},
+		"Internal Domains": {
+			options: &CrawlOptions{InternalSubdomains: true, SiblingDomains: []string{"google.org"}},
+			expected: &analyzer.OneDepthCrawler{CrawlConfig: analyzer.CrawlConfig{
+				InternalSubdomains: true,
+				SiblingDomains:     []string{"google.org"},
+			}},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if err := test.options.Validate(); err != nil {
+				t.Fatalf("Expected to be valid options, but got %v", err)
+			}
+			crawler := test.options.NewCrawler()
+			if !reflect.DeepEqual(crawler, test.expected) {
+				t.Errorf("Expected crawler %+v, but got %+v", test.expected, crawler)
+			}
+		})
+	}
+}
+
 func TestAnalyze_200_Success(t *testing.T) {
 	// GIVEN
 	w := httptest.NewRecorder()
 	r := mux.NewRouter()
-	AnalyzeEndPoint("/api").Register(r)
+	AnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
 
 	url := "https://www.google.com"
 	// WHEN
@@ -131,3 +267,482 @@ func TestAnalyze_200_Success(t *testing.T) {
 		t.Error("Expected at least to have one link, but got all zeros")
 	}
 }
+
+func TestBatchAnalyze_400_InvalidRequest(t *testing.T) {
+	testcases := map[string]struct {
+		requestBody string
+		errorCode   string
+		field       string
+	}{
+		"No Urls": {
+			requestBody: `{ "urls": [] }`,
+			errorCode:   InvalidBatchRequest,
+			field:       "urls",
+		},
+		"Both Urls And Sitemap": {
+			requestBody: `{ "urls": ["https://www.google.com"], "sitemapUrl": "https://www.google.com/sitemap.xml" }`,
+			errorCode:   InvalidBatchRequest,
+			field:       "sitemapUrl",
+		},
+		"Empty Url": {
+			requestBody: `{ "urls": ["https://www.google.com", ""] }`,
+			errorCode:   InvalidBatchRequest,
+			field:       "urls",
+		},
+		"Too Many Urls": {
+			requestBody: `{ "urls": [` + strings.Repeat(`"https://www.google.com",`, MaxAllowedBatchUrls) + `"https://www.google.com"] }`,
+			errorCode:   InvalidBatchRequest,
+			field:       "urls",
+		},
+		"Invalid Crawl Options": {
+			requestBody: `{ "urls": ["https://www.google.com"], "crawl": { "maxLinks": -5 } }`,
+			errorCode:   InvalidCrawlOptions,
+			field:       "crawl.maxLinks",
+		},
+	}
+
+	// GIVEN
+	r := mux.NewRouter()
+	BatchAnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze/batch", strings.NewReader(test.requestBody)))
+
+			// THEN
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected to have status code 400! Actual: %d", w.Code)
+			}
+			var errObj map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&errObj); err != nil {
+				t.Errorf("Expected to return a structured error object! Received: %s", err.Error())
+			}
+			if errObj["errorCode"] != test.errorCode {
+				t.Errorf("Expected to return %s error code, but got %s", test.errorCode, errObj["errorCode"])
+			} else if errObj["field"] != test.field {
+				t.Errorf("Expected to report field %s, but got %s", test.field, errObj["field"])
+			}
+		})
+	}
+}
+
+func TestBatchAnalyze_200_Sitemap(t *testing.T) {
+	// GIVEN
+	site := http.NewServeMux()
+	server := httptest.NewServer(site)
+	defer server.Close()
+	site.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<urlset><url><loc>%[1]s/</loc></url><url><loc>%[1]s/missing</loc></url></urlset>`, server.URL)
+	})
+	site.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Home</title></head><body><a href="/missing">missing</a></body></html>`)
+	})
+
+	w := httptest.NewRecorder()
+	r := mux.NewRouter()
+	BatchAnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	// WHEN
+	body := fmt.Sprintf(`{ "sitemapUrl": "%s/sitemap.xml" }`, server.URL)
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze/batch", strings.NewReader(body)))
+
+	// THEN
+	if w.Code != http.StatusOK {
+		t.Fatal("Not expected to throw an error! Actual:", w.Code)
+	}
+	var res BatchAnalyzeResponse
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("Expected to return a BatchAnalyzeResponse object! Received: %s", err.Error())
+	}
+
+	if len(res.Results) != 2 {
+		t.Fatalf("Expected to have 2 results, but got %d", len(res.Results))
+	} else if res.Results[0].Url != server.URL+"/" || res.Results[0].Error != nil {
+		t.Errorf("Expected the home page to be analyzed, but got %+v", res.Results[0])
+	} else if res.Results[0].Data.Title != "Home" || res.Results[0].Data.LinkStats.InvalidLinkCount != 1 {
+		t.Errorf("Expected the home page to have a broken link, but got %+v", res.Results[0].Data)
+	} else if res.Results[1].Data != nil || res.Results[1].Error.ErrorCode != analyzer.UnsuccessfulStatusCode {
+		t.Errorf("Expected the missing page to fail, but got %+v", res.Results[1])
+	}
+}
+
+func TestBatchAnalyze_500_InvalidSitemap(t *testing.T) {
+	// GIVEN
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html></html>`)
+	}))
+	defer site.Close()
+
+	w := httptest.NewRecorder()
+	r := mux.NewRouter()
+	BatchAnalyzeEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	// WHEN
+	body := fmt.Sprintf(`{ "sitemapUrl": "%s/sitemap.xml" }`, site.URL)
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze/batch", strings.NewReader(body)))
+
+	// THEN
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected to have status code 500! Actual: %d", w.Code)
+	}
+	var errObj map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&errObj); err != nil {
+		t.Errorf("Expected to return a structured error object! Received: %s", err.Error())
+	}
+	if errObj["errorCode"] != analyzer.InvalidSitemap {
+		t.Errorf("Expected to return %s error code, but got %s", analyzer.InvalidSitemap, errObj["errorCode"])
+	}
+}
+
+func TestJobs_Lifecycle(t *testing.T) {
+	// GIVEN
+	release := make(chan struct{})
+	site := http.NewServeMux()
+	site.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/fast">fast</a><a href="/missing">missing</a></body></html>`)
+	})
+	site.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {})
+	site.HandleFunc("/missing", http.NotFound)
+	site.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/blocked">blocked</a></body></html>`)
+	})
+	site.HandleFunc("/blocked", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	checkingResources := make(chan struct{}, 1)
+	site.HandleFunc("/gallery", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><body><img src="/photo.png"></body></html>`)
+	})
+	site.HandleFunc("/photo.png", func(w http.ResponseWriter, r *http.Request) {
+		checkingResources <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(site)
+	defer server.Close()
+	defer close(release)
+
+	r := mux.NewRouter()
+	jobs := NewJobManager(analyzer.NewAnalyzer(analyzer.Options{}), 1, time.Minute)
+	SubmitJobEndPoint("/api", jobs).Register(r)
+	GetJobEndPoint("/api", jobs).Register(r)
+	CancelJobEndPoint("/api", jobs).Register(r)
+
+	call := func(method, path, body string) (int, JobResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var res JobResponse
+		_ = json.NewDecoder(w.Body).Decode(&res)
+		return w.Code, res
+	}
+	waitFor := func(id string, states ...string) JobResponse {
+		for i := 0; i < 200; i++ {
+			_, res := call("GET", "/api/jobs/"+id, "")
+			for _, state := range states {
+				if res.State == state {
+					return res
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Job %s never reached to states %v", id, states)
+		return JobResponse{}
+	}
+
+	t.Run("Completes Job", func(t *testing.T) {
+		// WHEN
+		code, submitted := call("POST", "/api/jobs", fmt.Sprintf(`{ "url": "%s/" }`, server.URL))
+
+		// THEN
+		if code != http.StatusAccepted || submitted.Id == "" || submitted.State != JobQueued {
+			t.Fatalf("Expected to accept a queued job, but got %d %+v", code, submitted)
+		}
+		res := waitFor(submitted.Id, JobDone, JobFailed)
+		if res.State != JobDone || res.Result == nil || res.Result.LinkStats.InvalidLinkCount != 1 {
+			t.Errorf("Expected the job to be done with one invalid link, but got %+v", res)
+		} else if res.Progress != (JobProgress{PagesParsed: 1, TotalLinks: 2, CheckedLinks: 2, InvalidLinks: 1}) {
+			t.Errorf("Expected the full progress, but got %+v", res.Progress)
+		} else if res.FinishedAt == nil {
+			t.Errorf("Expected to have the finished time")
+		}
+	})
+
+	t.Run("Fails Job", func(t *testing.T) {
+		// WHEN
+		_, submitted := call("POST", "/api/jobs", fmt.Sprintf(`{ "url": "%s/missing" }`, server.URL))
+
+		// THEN
+		res := waitFor(submitted.Id, JobDone, JobFailed)
+		if res.State != JobFailed || res.Error == nil || res.Error.ErrorCode != analyzer.UnsuccessfulStatusCode {
+			t.Errorf("Expected the job to be failed, but got %+v", res)
+		}
+	})
+
+	t.Run("Cancels Jobs", func(t *testing.T) {
+		// GIVEN
+		_, running := call("POST", "/api/jobs", fmt.Sprintf(`{ "url": "%s/slow" }`, server.URL))
+		waitFor(running.Id, JobRunning)
+		// only one job runs at once, hence the next job waits in the queue.
+		_, queued := call("POST", "/api/jobs", fmt.Sprintf(`{ "url": "%s/" }`, server.URL))
+
+		// WHEN
+		code, res := call("DELETE", "/api/jobs/"+queued.Id, "")
+
+		// THEN
+		if code != http.StatusOK || res.State != JobCancelled {
+			t.Errorf("Expected the queued job to be cancelled immediately, but got %d %+v", code, res)
+		}
+
+		// WHEN
+		call("DELETE", "/api/jobs/"+running.Id, "")
+
+		// THEN
+		res = waitFor(running.Id, JobCancelled, JobDone, JobFailed)
+		if res.State != JobCancelled || res.Result == nil || !res.Result.LinkStats.Incomplete {
+			t.Errorf("Expected the running job to be cancelled with partial result, but got %+v", res)
+		}
+	})
+
+	t.Run("Cancels Job Checking Resources", func(t *testing.T) {
+		// GIVEN
+		_, running := call("POST", "/api/jobs", fmt.Sprintf(`{ "url": "%s/gallery" }`, server.URL))
+		<-checkingResources
+
+		// WHEN
+		call("DELETE", "/api/jobs/"+running.Id, "")
+
+		// THEN
+		res := waitFor(running.Id, JobCancelled, JobDone, JobFailed)
+		if res.State != JobCancelled || res.Result == nil || !res.Result.ResourceStats.Incomplete {
+			t.Errorf("Expected the job to be cancelled with partial resources, but got %+v", res)
+		}
+	})
+
+	t.Run("Unknown Job", func(t *testing.T) {
+		for _, method := range []string{"GET", "DELETE"} {
+			// WHEN
+			code, _ := call(method, "/api/jobs/unknown", "")
+
+			// THEN
+			if code != http.StatusNotFound {
+				t.Errorf("Expected to have status code 404 for %s! Actual: %d", method, code)
+			}
+		}
+	})
+
+	t.Run("Invalid Request", func(t *testing.T) {
+		// WHEN
+		code, _ := call("POST", "/api/jobs", `{ "url": "https://www.google.com", "crawl": { "maxLinks": -5 } }`)
+
+		// THEN
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected to have status code 400! Actual: %d", code)
+		}
+	})
+}
+
+func TestAnalyzeStream_Events(t *testing.T) {
+	// GIVEN
+	site := http.NewServeMux()
+	site.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/ok">ok</a><a href="/missing">missing</a></body></html>`)
+	})
+	site.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	site.HandleFunc("/missing", http.NotFound)
+	server := httptest.NewServer(site)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	r := mux.NewRouter()
+	AnalyzeStreamEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	// WHEN
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/analyze/stream?url="+server.URL+"/&maxLinks=5", nil))
+
+	// THEN
+	if w.Code != http.StatusOK {
+		t.Fatal("Not expected to throw an error! Actual:", w.Code)
+	} else if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected to have an event stream, but got %s", w.Header().Get("Content-Type"))
+	}
+
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	names := []string{}
+	for _, event := range events {
+		name, _, _ := strings.Cut(strings.TrimPrefix(event, "event: "), "\n")
+		names = append(names, name)
+	}
+	expected := []string{PageParsedEvent, LinkCheckedEvent, LinkCheckedEvent, CompletedEvent}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected events %v, but got %v", expected, names)
+	}
+
+	var parsed PageParsedMessage
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(strings.Split(events[0], "\n")[1], "data: ")), &parsed); err != nil {
+		t.Errorf("Expected to have a page parsed message! Received: %s", err.Error())
+	} else if parsed.PageUrl != server.URL+"/" || parsed.LinkCount != 2 || parsed.PendingLinkCount != 2 {
+		t.Errorf("Expected to have two pending links, but got %+v", parsed)
+	}
+
+	var result analyzer.AnalysisData
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(strings.Split(events[3], "\n")[1], "data: ")), &result); err != nil {
+		t.Errorf("Expected to have the analysis data! Received: %s", err.Error())
+	} else if result.LinkStats.InvalidLinkCount != 1 {
+		t.Errorf("Expected to have one invalid link, but got %d", result.LinkStats.InvalidLinkCount)
+	}
+}
+
+func TestAnalyzeStream_Errors(t *testing.T) {
+	testcases := map[string]struct {
+		query      string
+		statusCode int
+		event      string
+	}{
+		"No Url": {
+			query:      "",
+			statusCode: http.StatusBadRequest,
+		},
+		"Malformed Crawl Option": {
+			query:      "url=https://www.google.com&maxLinks=ten",
+			statusCode: http.StatusBadRequest,
+		},
+		"Invalid Crawl Option": {
+			query:      "url=https://www.google.com&strategy=random",
+			statusCode: http.StatusBadRequest,
+		},
+		"Unknown Inspector": {
+			query:      "url=https://www.google.com&inspectors=title&inspectors=random",
+			statusCode: http.StatusBadRequest,
+		},
+		"Failed Analysis": {
+			query:      "url=ftp://www.google.com",
+			statusCode: http.StatusOK,
+			event:      FailedEvent,
+		},
+	}
+
+	// GIVEN
+	r := mux.NewRouter()
+	AnalyzeStreamEndPoint("/api", analyzer.NewAnalyzer(analyzer.Options{})).Register(r)
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/api/analyze/stream?"+test.query, nil))
+
+			// THEN
+			if w.Code != test.statusCode {
+				t.Errorf("Expected to have status code %d! Actual: %d", test.statusCode, w.Code)
+			} else if test.event != "" && !strings.HasPrefix(w.Body.String(), "event: "+test.event+"\n") {
+				t.Errorf("Expected to have %s event, but got %s", test.event, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCrawlOptionsOf(t *testing.T) {
+	verifyExternal := false
+	testcases := map[string]struct {
+		query    string
+		expected *CrawlOptions
+	}{
+		"No Options": {
+			query:    "url=https://www.google.com",
+			expected: nil,
+		},
+		"All Options": {
+			query: "strategy=depth&maxDepth=2&maxLinks=10&linkTimeoutMs=500&verifyExternal=false" +
+				"&internalSubdomains=true&siblingDomains=a.com&siblingDomains=b.com",
+			expected: &CrawlOptions{Strategy: DepthStrategy, MaxDepth: 2, MaxLinks: 10, LinkTimeoutMs: 500,
+				VerifyExternal: &verifyExternal, InternalSubdomains: true, SiblingDomains: []string{"a.com", "b.com"}},
+		},
+	}
+
+	for name, test := range testcases {
+		t.Run(name, func(t *testing.T) {
+			query, _ := url.ParseQuery(test.query)
+			options, err := crawlOptionsOf(query)
+			if err != nil {
+				t.Fatalf("Expected to be valid options, but got %v", err)
+			} else if !reflect.DeepEqual(options, test.expected) {
+				t.Errorf("Expected options %+v, but got %+v", test.expected, options)
+			}
+		})
+	}
+}
+
+func TestAnalyze_200_Cache(t *testing.T) {
+	// GIVEN
+	fetches := 0
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches++
+		w.Header().Set("content-type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Cached</title></head></html>`)
+	}))
+	defer site.Close()
+
+	r := mux.NewRouter()
+	a := analyzer.NewAnalyzer(analyzer.Options{Cache: analyzer.NewMemoryCache(10, time.Minute)})
+	AnalyzeEndPoint("/api", a).Register(r)
+
+	testcases := []struct {
+		name        string
+		noCache     bool
+		inspectors  string
+		cacheHeader string
+		fetches     int
+	}{
+		{name: "First Analysis", cacheHeader: "MISS", fetches: 1},
+		{name: "Cached Analysis", cacheHeader: "HIT", fetches: 1},
+		{name: "Bypassed Cache", noCache: true, cacheHeader: "MISS", fetches: 2},
+		{name: "Other Inspectors", inspectors: `"title"`, cacheHeader: "MISS", fetches: 3},
+		{name: "Cached Inspectors", inspectors: `"title"`, cacheHeader: "HIT", fetches: 3},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			// WHEN
+			w := httptest.NewRecorder()
+			body := fmt.Sprintf(`{ "url": "%s", "noCache": %t, "inspectors": [%s] }`, site.URL, test.noCache, test.inspectors)
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", strings.NewReader(body)))
+
+			// THEN
+			var res analyzer.AnalysisData
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatalf("Expected to return an AnlaysisData object! Received: %s", err.Error())
+			}
+			if w.Header().Get("X-Cache") != test.cacheHeader {
+				t.Errorf("Expected X-Cache header to be %s, but got %s", test.cacheHeader, w.Header().Get("X-Cache"))
+			} else if test.cacheHeader == "HIT" && (res.Cache == nil || w.Header().Get("Age") != "0") {
+				t.Errorf("Expected to have the cache status and age, but got %+v %s", res.Cache, w.Header().Get("Age"))
+			} else if test.cacheHeader == "MISS" && res.Cache != nil {
+				t.Errorf("Expected not to have a cache status, but got %+v", res.Cache)
+			} else if fetches != test.fetches {
+				t.Errorf("Expected the page to be fetched %d times, but got %d", test.fetches, fetches)
+			} else if res.Title != "Cached" {
+				t.Errorf("Expected Title to be Cached, but got %s", res.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"linklens/analyzer"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// JobManager runs analyses in the background, and keeps track of their progress.
+// Only a limited number of jobs run at the same time, while the rest are queued.
+// Finished jobs are kept until the retention period elapses.
+type JobManager struct {
+	analyzer  *analyzer.Analyzer
+	slots     chan struct{}
+	retention time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+type job struct {
+	id      string
+	request AnalyzeRequest
+	cancel  context.CancelFunc
+
+	mu         sync.Mutex
+	state      string
+	progress   JobProgress
+	result     *analyzer.AnalysisData
+	err        error
+	createdAt  time.Time
+	finishedAt time.Time
+}
+
+// NewJobManager creates a job manager running maximum of the given number of jobs at once.
+// Non-positive values fallback to the defaults.
+func NewJobManager(a *analyzer.Analyzer, maxRunningJobs int, retention time.Duration) *JobManager {
+	if maxRunningJobs <= 0 {
+		maxRunningJobs = DefaultMaxRunningJobs
+	}
+	if retention <= 0 {
+		retention = DefaultJobRetention
+	}
+	return &JobManager{
+		analyzer:  a,
+		slots:     make(chan struct{}, maxRunningJobs),
+		retention: retention,
+		jobs:      map[string]*job{},
+	}
+}
+
+// Submit queues a new job analyzing the given request and returns its current status.
+// The request must be validated before calling this.
+func (m *JobManager) Submit(req AnalyzeRequest) JobResponse {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{id: newJobId(), request: req, cancel: cancel, state: JobQueued, createdAt: time.Now()}
+
+	m.mu.Lock()
+	m.removeExpiredJobs()
+	m.jobs[j.id] = j
+	m.mu.Unlock()
+
+	res := j.status()
+	go m.run(ctx, j)
+	return res
+}
+
+// Get returns the current status of the given job, or false if no such job exists.
+func (m *JobManager) Get(id string) (JobResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpiredJobs()
+	j, ok := m.jobs[id]
+	if !ok {
+		return JobResponse{}, false
+	}
+	return j.status(), true
+}
+
+// Cancel cancels the given job, if it is not finished yet, and returns its status.
+// A running job will be cancelled after verifying the links in progress,
+// and its partial result is kept. It returns false if no such job exists.
+func (m *JobManager) Cancel(id string) (JobResponse, bool) {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return JobResponse{}, false
+	}
+
+	j.mu.Lock()
+	if j.state == JobQueued {
+		j.state = JobCancelled
+		j.finishedAt = time.Now()
+	}
+	j.mu.Unlock()
+	j.cancel()
+	return j.status(), true
+}
+
+func (m *JobManager) run(ctx context.Context, j *job) {
+	defer j.cancel()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		return
+	}
+
+	if !j.transition(JobQueued, JobRunning) {
+		// cancelled while waiting for a slot
+		return
+	}
+
+	slog.Info("Running the job", "id", j.id, "url", j.request.Url)
+	a := analyzerOf(m.analyzer, j.request.NoCache, j.request.Inspectors).WithListener(j.onProgress)
+	result, err := a.AnalyzeUrl(ctx, j.request.Url, j.request.Crawl.NewCrawler())
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.result, j.err = result, err
+	j.finishedAt = time.Now()
+	if err != nil && ctx.Err() != nil {
+		j.state = JobCancelled
+	} else if err != nil {
+		j.state = JobFailed
+	} else if ctx.Err() != nil && (result.LinkStats.Incomplete || result.ResourceStats.Incomplete) {
+		j.state = JobCancelled
+	} else {
+		j.state = JobDone
+	}
+	slog.Info("Finished the job", "id", j.id, "state", j.state)
+}
+
+// removeExpiredJobs removes all jobs finished before the retention period.
+// The caller must hold the lock.
+func (m *JobManager) removeExpiredJobs() {
+	for id, j := range m.jobs {
+		j.mu.Lock()
+		expired := !j.finishedAt.IsZero() && time.Since(j.finishedAt) > m.retention
+		j.mu.Unlock()
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// transition changes the state of the job, only if the job is in the expected state.
+func (j *job) transition(from, to string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state != from {
+		return false
+	}
+	j.state = to
+	return true
+}
+
+func (j *job) onProgress(event analyzer.ProgressEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch event.Type {
+	case analyzer.PageParsedEvent:
+		j.progress.PagesParsed++
+		j.progress.TotalLinks += event.PendingLinkCount
+	case analyzer.LinkCheckedEvent:
+		j.progress.CheckedLinks++
+		if !event.Link.IsValid && !event.Link.BlockedByRobots {
+			j.progress.InvalidLinks++
+		}
+	}
+}
+
+func (j *job) status() JobResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	res := JobResponse{
+		Id:        j.id,
+		Url:       j.request.Url,
+		State:     j.state,
+		Progress:  j.progress,
+		Result:    j.result,
+		CreatedAt: j.createdAt,
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		res.FinishedAt = &finishedAt
+	}
+	if j.state == JobFailed {
+		res.Error = newErrorDetail(j.err)
+	}
+	return res
+}
+
+func newJobId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// should never happen, as the random source of the system never fails.
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"linklens/analyzer"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// eventStream writes server-sent events to the response.
+// It is safe to send events concurrently.
+type eventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newEventStream starts a server-sent event stream in the given response,
+// or returns false if the response cannot be streamed.
+func newEventStream(w http.ResponseWriter) (*eventStream, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &eventStream{w: w, flusher: flusher}, true
+}
+
+// send writes the given data as json in an event of the given name.
+func (s *eventStream) send(name string, data any) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Error encoding the event!", "event", name, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logErrIf(fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, content))
+	s.flusher.Flush()
+}
+
+// onProgress forwards the progress of the analysis as events.
+func (s *eventStream) onProgress(event analyzer.ProgressEvent) {
+	switch event.Type {
+	case analyzer.PageParsedEvent:
+		s.send(PageParsedEvent, PageParsedMessage{
+			PageUrl:          event.PageUrl,
+			LinkCount:        event.LinkCount,
+			PendingLinkCount: event.PendingLinkCount,
+		})
+	case analyzer.LinkCheckedEvent:
+		s.send(LinkCheckedEvent, LinkCheckedMessage{PageUrl: event.PageUrl, Link: *event.Link})
+	}
+}
+
+// crawlOptionsOf reads the crawl options from the query parameters having the
+// same names as in the json request. It returns nil if no option is given.
+func crawlOptionsOf(query url.Values) (*CrawlOptions, error) {
+	var o CrawlOptions
+	found := false
+	intParam := func(name string, target *int) error {
+		if value := query.Get(name); value != "" {
+			found = true
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return &ValidationError{
+					ErrorCode: InvalidCrawlOptions,
+					Field:     "crawl." + name,
+					Message:   fmt.Sprintf("%s must be an integer", name),
+				}
+			}
+			*target = parsed
+		}
+		return nil
+	}
+	boolParam := func(name string) (bool, error) {
+		parsed, err := strconv.ParseBool(query.Get(name))
+		if err != nil {
+			return false, &ValidationError{
+				ErrorCode: InvalidCrawlOptions,
+				Field:     "crawl." + name,
+				Message:   fmt.Sprintf("%s must be either true or false", name),
+			}
+		}
+		found = true
+		return parsed, nil
+	}
+
+	if value := query.Get("strategy"); value != "" {
+		o.Strategy = value
+		found = true
+	}
+	if err := intParam("maxDepth", &o.MaxDepth); err != nil {
+		return nil, err
+	} else if err := intParam("maxLinks", &o.MaxLinks); err != nil {
+		return nil, err
+	} else if err := intParam("linkTimeoutMs", &o.LinkTimeoutMs); err != nil {
+		return nil, err
+	}
+	if query.Has("verifyExternal") {
+		verifyExternal, err := boolParam("verifyExternal")
+		if err != nil {
+			return nil, err
+		}
+		o.VerifyExternal = &verifyExternal
+	}
+	if query.Has("internalSubdomains") {
+		internalSubdomains, err := boolParam("internalSubdomains")
+		if err != nil {
+			return nil, err
+		}
+		o.InternalSubdomains = internalSubdomains
+	}
+	if domains, ok := query["siblingDomains"]; ok {
+		o.SiblingDomains = domains
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return &o, nil
+}
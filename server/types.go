@@ -1,7 +1,139 @@
 package server
 
+import (
+	"linklens/analyzer"
+	"time"
+)
+
+const (
+	OneDepthStrategy = "oneDepth"
+	DepthStrategy    = "depth"
+)
+
+const (
+	// Maximum depth a client can request when using the depth strategy.
+	MaxAllowedDepth = 5
+	// Maximum per link timeout a client can request in milliseconds.
+	MaxAllowedLinkTimeoutMs = 60_000
+	// Maximum number of urls analyzed in a single batch.
+	MaxAllowedBatchUrls = 50
+)
+
+const (
+	InvalidCrawlOptions = "InvalidCrawlOptions"
+	InvalidBatchRequest = "InvalidBatchRequest"
+	InvalidInspectors   = "InvalidInspectors"
+	JobNotFound         = "JobNotFound"
+)
+
+// States of an analysis job
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Names of events sent in the analysis stream
+const (
+	PageParsedEvent  = "pageParsed"
+	LinkCheckedEvent = "linkChecked"
+	CompletedEvent   = "completed"
+	FailedEvent      = "failed"
+)
+
+const (
+	// Default number of jobs running at the same time.
+	DefaultMaxRunningJobs = 4
+	// Default duration to keep finished jobs, until clients fetch their results.
+	DefaultJobRetention = time.Hour
+)
+
 type AnalyzeRequest struct {
-	Url string `json:"url"`
+	Url   string        `json:"url"`
+	Crawl *CrawlOptions `json:"crawl"`
+	// Whether to analyze again ignoring any cached result.
+	NoCache bool `json:"noCache"`
+	// Names of the inspectors to run. All inspectors run when omitted.
+	Inspectors []string `json:"inspectors"`
+}
+
+// Options controlling how links in the analyzed page are crawled.
+// All fields are optional and when omitted, a one depth crawl is performed.
+type CrawlOptions struct {
+	Strategy       string `json:"strategy"`
+	MaxDepth       int    `json:"maxDepth"`
+	MaxLinks       int    `json:"maxLinks"`
+	VerifyExternal *bool  `json:"verifyExternal"`
+	LinkTimeoutMs  int    `json:"linkTimeoutMs"`
+	// Whether links to subdomains of the site are counted as internal links.
+	InternalSubdomains bool `json:"internalSubdomains"`
+	// Other domains whose links are counted as internal links.
+	SiblingDomains []string `json:"siblingDomains"`
+}
+
+// Request to analyze many urls at once. Either the urls or a sitemap url
+// listing the urls must be given. Same crawl options are used for all urls.
+type BatchAnalyzeRequest struct {
+	Urls       []string      `json:"urls"`
+	SitemapUrl string        `json:"sitemapUrl"`
+	Crawl      *CrawlOptions `json:"crawl"`
+	NoCache    bool          `json:"noCache"`
+	Inspectors []string      `json:"inspectors"`
+}
+
+type BatchAnalyzeResponse struct {
+	Results []BatchResult `json:"results"`
+	// Whether the sitemap had more urls than allowed, hence only the first urls are analyzed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Result of a single url in a batch, having either the analysis data or the error.
+type BatchResult struct {
+	Url   string                 `json:"url"`
+	Data  *analyzer.AnalysisData `json:"data,omitempty"`
+	Error *ErrorDetail           `json:"error,omitempty"`
+}
+
+// Status of an analysis job. Result is available once the job is done, and the
+// partial result is available if the job was cancelled while running.
+type JobResponse struct {
+	Id         string                 `json:"id"`
+	Url        string                 `json:"url"`
+	State      string                 `json:"state"`
+	Progress   JobProgress            `json:"progress"`
+	Result     *analyzer.AnalysisData `json:"result,omitempty"`
+	Error      *ErrorDetail           `json:"error,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	FinishedAt *time.Time             `json:"finishedAt,omitempty"`
+}
+
+// Progress of an analysis job. Total links grows as more pages are parsed.
+type JobProgress struct {
+	PagesParsed  int `json:"pagesParsed"`
+	TotalLinks   int `json:"totalLinks"`
+	CheckedLinks int `json:"checkedLinks"`
+	InvalidLinks int `json:"invalidLinks"`
+}
+
+// Sent when a page is parsed, and its links are about to be verified.
+type PageParsedMessage struct {
+	PageUrl          string `json:"pageUrl"`
+	LinkCount        int    `json:"linkCount"`
+	PendingLinkCount int    `json:"pendingLinkCount"`
+}
+
+// Sent when a link of a page is verified.
+type LinkCheckedMessage struct {
+	PageUrl string              `json:"pageUrl"`
+	Link    analyzer.LinkStatus `json:"link"`
+}
+
+// Reason of a failed analysis.
+type ErrorDetail struct {
+	ErrorCode string `json:"errorCode,omitempty"`
+	Message   string `json:"message"`
 }
 
 type ErrorResponse struct {
@@ -11,3 +143,14 @@ type ErrorResponse struct {
 type HealthResponse struct {
 	Alive bool `json:"alive"`
 }
+
+// Validation error of a single field in the request body.
+type ValidationError struct {
+	ErrorCode string
+	Field     string
+	Message   string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
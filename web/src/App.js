@@ -1,19 +1,26 @@
-import { useState, useCallback } from "react";
-import { LinkInfo } from "./LinkInfo";
+import { useState, useCallback, useRef, useEffect } from "react";
+import { LinkInfo, Progress } from "./LinkInfo";
 import "./App.css";
 
+const INITIAL_PROGRESS = { total: 0, checked: 0, brokenLinks: [] };
+
 function App() {
   const [url, setUrl] = useState("");
   const [isLoading, setLoading] = useState(false);
   const [data, setData] = useState(null);
   const [errorObj, setError] = useState(null);
   const [elapsed, setElapsed] = useState(-1);
+  const [progress, setProgress] = useState(INITIAL_PROGRESS);
+  const sourceRef = useRef(null);
 
   const handleUrlChange = useCallback((e) => {
     setUrl(e.target.value);
   }, []);
 
-  const handleAnalyze = useCallback(async () => {
+  // close the stream, if the page is closed while analyzing.
+  useEffect(() => () => sourceRef.current && sourceRef.current.close(), []);
+
+  const handleAnalyze = useCallback(() => {
     if (isLoading) {
       return;
     }
@@ -22,28 +29,48 @@ function App() {
     setData(null);
     setLoading(true);
     setElapsed(-1);
+    setProgress(INITIAL_PROGRESS);
     const t1 = Date.now();
-    try {
-      const res = await fetch("/api/analyze", {
-        method: "POST",
-        body: JSON.stringify({
-          url,
-        }),
-      });
 
-      const json = await res.json();
-      if (!res.ok) {
-        setError(json);
-      } else {
-        setData(json);
-      }
-    } catch (err) {
-      setError(err);
-      console.log(err);
-    } finally {
+    const source = new EventSource(
+      `/api/analyze/stream?url=${encodeURIComponent(url)}`
+    );
+    sourceRef.current = source;
+    const finish = () => {
+      source.close();
+      sourceRef.current = null;
       setLoading(false);
       setElapsed(Date.now() - t1);
-    }
+    };
+
+    source.addEventListener("pageParsed", (e) => {
+      const page = JSON.parse(e.data);
+      setProgress((p) => ({ ...p, total: p.total + page.pendingLinkCount }));
+    });
+    source.addEventListener("linkChecked", (e) => {
+      const { link } = JSON.parse(e.data);
+      setProgress((p) => ({
+        ...p,
+        checked: p.checked + 1,
+        brokenLinks:
+          link.IsValid || link.BlockedByRobots
+            ? p.brokenLinks
+            : [...p.brokenLinks, link],
+      }));
+    });
+    source.addEventListener("completed", (e) => {
+      setData(JSON.parse(e.data));
+      finish();
+    });
+    source.addEventListener("failed", (e) => {
+      setError(JSON.parse(e.data));
+      finish();
+    });
+    source.onerror = () => {
+      // the stream is closed by the server only after the final event.
+      setError({ message: "Connection to the server is lost!" });
+      finish();
+    };
   }, [url, isLoading]);
 
   return (
@@ -75,6 +102,7 @@ function App() {
           {errorObj && (
             <div class="err-label">Error! {errorObj["message"]}</div>
           )}
+          {isLoading && <Progress {...progress} />}
           {data && <LinkInfo data={data} elapsed={elapsed} />}
         </div>
       </div>
@@ -21,12 +21,62 @@ const Chip = ({ label, value, bgColor, valueBgColor, color }) => {
   );
 };
 
+const describeInvalidLink = (link) => {
+  const reason =
+    link.StatusCode && link.StatusCode !== 999
+      ? `${link.StatusCode} ${link.ErrorCategory || ""}`
+      : link.ErrorCategory;
+  return `${link.Url || link.Href} (${reason.trim()})${
+    link.Text ? ` - "${link.Text}"` : ""
+  }`;
+};
+
+export const Progress = ({ total, checked, brokenLinks }) => {
+  const percentage = total > 0 ? Math.round((checked * 100) / total) : 0;
+
+  return (
+    <div className="result-panel">
+      <DataRow
+        label={"Checked Links:"}
+        value={
+          <div className="progress">
+            <div className="progress-bar" style={{ width: `${percentage}%` }} />
+            <div className="progress-label">
+              {checked} / {total}
+            </div>
+          </div>
+        }
+      />
+      {brokenLinks.length > 0 && (
+        <DataRow
+          id="invalid-links"
+          label={"Invalid Links:"}
+          value={
+            <div>
+              {brokenLinks.map((l) => (
+                <div>• {describeInvalidLink(l)}</div>
+              ))}
+            </div>
+          }
+        />
+      )}
+    </div>
+  );
+};
+
 const LinkStatsSection = ({
   InternalLinkCount = 0,
   ExternalLinkCount = 0,
   InvalidLinkCount = 0,
   InvalidLinks = [],
+  BlockedLinkCount = 0,
+  Links = [],
 }) => {
+  const invalidLinkDetails =
+    Links && Links.length > 0
+      ? Links.filter((l) => l.Verified && !l.IsValid).map(describeInvalidLink)
+      : InvalidLinks;
+
   return (
     <>
       <DataRow
@@ -42,16 +92,19 @@ const LinkStatsSection = ({
               valueBgColor={"#ff000022"}
               color={"#ff0000"}
             />
+            {BlockedLinkCount > 0 && (
+              <Chip label={"Blocked by Robots"} value={BlockedLinkCount} />
+            )}
           </div>
         }
       />
-      {InvalidLinks && InvalidLinks.length > 0 && (
+      {invalidLinkDetails && invalidLinkDetails.length > 0 && (
         <DataRow
           id="invalid-links"
           label={"Invalid Links:"}
           value={
             <div>
-              {InvalidLinks.map((l) => (
+              {invalidLinkDetails.map((l) => (
                 <div>• {l}</div>
               ))}
             </div>
@@ -82,6 +135,160 @@ const HeadingsSection = ({ HeadingsCount }) => {
   );
 };
 
+const OutlineItems = ({ headings }) => {
+  return (
+    <ul className="outline">
+      {headings.map((heading) => (
+        <li>
+          H{heading.Level} {heading.Text || <i>(empty)</i>}
+          {heading.SkippedLevels > 0 && (
+            <span style={{ color: "#ff0000" }}>
+              {" "}
+              - skipped {heading.SkippedLevels} level(s)
+            </span>
+          )}
+          {heading.Children && <OutlineItems headings={heading.Children} />}
+        </li>
+      ))}
+    </ul>
+  );
+};
+
+const OutlineSection = ({ Outline }) => {
+  return (
+    <DataRow
+      id="outline"
+      label={"Outline:"}
+      value={<OutlineItems headings={Outline} />}
+    />
+  );
+};
+
+const describeFormIssues = (form) => {
+  const issues = [];
+  if (form.InsecurePassword) issues.push("password sent over http");
+  if (form.MissingCsrfToken) issues.push("no CSRF token");
+  if (form.CrossOriginAction) issues.push("submitted to another origin");
+  return issues;
+};
+
+const FormsSection = ({ Forms }) => {
+  return (
+    <DataRow
+      id="forms"
+      label={"Forms:"}
+      value={
+        <div>
+          {Forms.map((form) => {
+            const issues = describeFormIssues(form);
+            return (
+              <div>
+                • {form.Method} {form.Action} ({form.Fields.length} fields)
+                {issues.length > 0 && (
+                  <span style={{ color: "#ff0000" }}>
+                    {" "}
+                    - {issues.join(", ")}
+                  </span>
+                )}
+              </div>
+            );
+          })}
+        </div>
+      }
+    />
+  );
+};
+
+const ResourceStatsSection = ({
+  BrokenResourceCount = 0,
+  Types = {},
+  Resources = [],
+}) => {
+  const brokenResources = Resources.filter((r) => r.Verified && !r.IsValid);
+
+  return (
+    <>
+      <DataRow
+        label={"Resources:"}
+        value={
+          <div style={{ display: "flex", gap: "4px" }}>
+            {Object.keys(Types).map((type) => (
+              <Chip label={type} value={Types[type].Count} />
+            ))}
+            <Chip
+              label={"Broken"}
+              value={BrokenResourceCount}
+              bgColor={"#ff000011"}
+              valueBgColor={"#ff000022"}
+              color={"#ff0000"}
+            />
+          </div>
+        }
+      />
+      {brokenResources.length > 0 && (
+        <DataRow
+          id="broken-resources"
+          label={"Broken Resources:"}
+          value={
+            <div>
+              {brokenResources.map((r) => (
+                <div>
+                  • [{r.Type}] {describeInvalidLink(r)}
+                </div>
+              ))}
+            </div>
+          }
+        />
+      )}
+    </>
+  );
+};
+
+const SeoSection = ({ Metadata = {}, SeoIssues = [] }) => {
+  return (
+    <>
+      {Metadata.Description && (
+        <DataRow label={"Description:"} value={Metadata.Description} />
+      )}
+      {Metadata.Canonical && (
+        <DataRow label={"Canonical:"} value={Metadata.Canonical} />
+      )}
+      {SeoIssues.length > 0 && (
+        <DataRow
+          id="seo-issues"
+          label={"SEO Issues:"}
+          value={
+            <div>
+              {SeoIssues.map((issue) => (
+                <div title={issue.Code}>• {issue.Message}</div>
+              ))}
+            </div>
+          }
+        />
+      )}
+    </>
+  );
+};
+
+const AccessibilitySection = ({ AccessibilityIssues }) => {
+  return (
+    <DataRow
+      id="accessibility-issues"
+      label={"Accessibility Issues:"}
+      value={
+        <div>
+          {AccessibilityIssues.map((issue) => (
+            <div title={issue.Code}>
+              • {issue.Message}
+              {issue.Element && <code> {issue.Element}</code>}
+            </div>
+          ))}
+        </div>
+      }
+    />
+  );
+};
+
 const DataRow = ({ id, label, value }) => {
   return (
     <div id={id} className="datarow">
@@ -95,13 +302,27 @@ const formatElapsed = (elapsed) => {
   return Math.fround((elapsed / 1000.0) * 1000) / 1000;
 };
 
+const formatDoctype = ({ Present, Variant, Mode }) => {
+  const details = [Present ? Variant : "no doctype", Mode && `${Mode} mode`];
+  return details.filter(Boolean).join(", ");
+};
+
 export const LinkInfo = ({ data, elapsed = -1 }) => {
   const {
     HtmlVersion,
+    Doctype = {},
     Title,
     HeadingsCount = {},
+    Outline = [],
     LinkStats = {},
+    ResourceStats = {},
     PageType,
+    PageTypeConfidence,
+    PageTypeSignals = [],
+    Forms = [],
+    Metadata = {},
+    SeoIssues = [],
+    AccessibilityIssues = [],
   } = data;
 
   return (
@@ -113,16 +334,41 @@ export const LinkInfo = ({ data, elapsed = -1 }) => {
           Elapsed: {formatElapsed(elapsed)} seconds
         </div>
       )}
-      <DataRow label={"HTML Version:"} value={HtmlVersion} />
+      <DataRow
+        label={"HTML Version:"}
+        value={`${HtmlVersion} (${formatDoctype(Doctype)})`}
+      />
       <DataRow label={"Title:"} value={Title} />
-      <DataRow label={"Page Type:"} value={PageType} />
+      <DataRow
+        label={"Page Type:"}
+        value={
+          PageTypeConfidence ? (
+            <div title={PageTypeSignals.join("\n")}>
+              {PageType} ({Math.round(PageTypeConfidence * 100)}% confident)
+            </div>
+          ) : (
+            PageType
+          )
+        }
+      />
+      <SeoSection Metadata={Metadata} SeoIssues={SeoIssues} />
       <HeadingsSection HeadingsCount={HeadingsCount} />
+      {Outline.length > 0 && <OutlineSection Outline={Outline} />}
+      {Forms.length > 0 && <FormsSection Forms={Forms} />}
+      {AccessibilityIssues.length > 0 && (
+        <AccessibilitySection AccessibilityIssues={AccessibilityIssues} />
+      )}
       <LinkStatsSection
         InternalLinkCount={LinkStats["InternalLinkCount"]}
         ExternalLinkCount={LinkStats["ExternalLinkCount"]}
         InvalidLinkCount={LinkStats["InvalidLinkCount"]}
         InvalidLinks={LinkStats["InvalidLinks"]}
+        BlockedLinkCount={LinkStats["BlockedLinkCount"]}
+        Links={LinkStats["Links"]}
       />
+      {ResourceStats["ResourceCount"] > 0 && (
+        <ResourceStatsSection {...ResourceStats} />
+      )}
     </div>
   );
 };